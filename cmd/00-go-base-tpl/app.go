@@ -4,11 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
-	"net/http"
+	"sync"
 	"time"
 
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
@@ -16,104 +14,166 @@ type Setupper interface {
 	Setup(ctx context.Context) error
 }
 
+// SetupperFunc adapts a function to Setupper.
+type SetupperFunc func(ctx context.Context) error
+
+func (f SetupperFunc) Setup(ctx context.Context) error {
+	return f(ctx)
+}
+
+type Runner func(ctx context.Context) error
+
+// App starts registered components in dependency order, runs them until the
+// context is cancelled or any of them returns, then stops them in reverse.
 type App struct {
 	log *zap.Logger
 
 	startupTimeout  time.Duration
 	shutdownTimeout time.Duration
 
-	setuppers []Setupper
-
-	mongoClient *mongo.Client
+	components []Component
+}
 
-	serverListener net.Listener
-	server         *http.Server
+func (a *App) Register(components ...Component) {
+	a.components = append(a.components, components...)
 }
 
 func (a *App) Run(ctx context.Context) error {
+	components, err := sortComponents(a.components)
+	if err != nil {
+		return fmt.Errorf("order components: %w", err)
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	if err := a.boot(ctx); err != nil {
-		return fmt.Errorf("boot: %w", err)
+	appErrs := make([]error, 0, 3)
+
+	started, err := a.start(ctx, components)
+	if err != nil {
+		appErrs = append(appErrs, fmt.Errorf("start: %w", err))
 	}
 
-	appErrs := make([]error, 0, 2)
+	var wg sync.WaitGroup
 
-	if err := a.run(ctx); err != nil {
-		if !errors.Is(err, context.Canceled) {
+	if err == nil {
+		if err := a.run(ctx, cancel, &wg, started); err != nil {
 			appErrs = append(appErrs, fmt.Errorf("run: %w", err))
 		}
 	}
 
+	cancel()
+
 	shutdownCtx, cancelShutdownTimeout := context.WithTimeout(context.Background(), a.shutdownTimeout)
 	defer cancelShutdownTimeout()
 
-	if err := a.shutdown(shutdownCtx); err != nil {
+	if err := a.stop(shutdownCtx, started, &wg); err != nil {
 		appErrs = append(appErrs, fmt.Errorf("shutdown: %w", err))
 	}
 
 	return errors.Join(appErrs...)
 }
 
-func (a *App) boot(ctx context.Context) error {
-	// todo
-	//if err := a.mongoClient.Connect(ctx); err != nil {
-	//	return fmt.Errorf("mongo client: connect: %w", err)
-	//}
-	//
-	//if err := a.mongoClient.Ping(ctx, nil); err != nil {
-	//	return fmt.Errorf("mongo client: ping: %w", err)
-	//}
-
-	//for i, setupper := range a.setuppers {
-	//	if err := setupper.Setup(ctx); err != nil {
-	//		return fmt.Errorf("setup[%d]: %w", i, err)
-	//	}
-	//}
-
-	return nil
-}
-
-func (a *App) run(ctx context.Context) error {
-	errCh := make(chan error)
+// start returns the components started before a failure so they can still
+// be stopped.
+func (a *App) start(ctx context.Context, components []Component) ([]Component, error) {
+	startCtx, cancel := context.WithTimeout(ctx, a.startupTimeout)
+	defer cancel()
 
-	go func() {
-		//a.log.Info("http server started", zap.String("addr", a.serverListener.Addr().String()))
+	begin := time.Now()
 
-		err := a.server.Serve(a.serverListener)
-		if err == nil {
-			return
+	for i, c := range components {
+		if c.Start != nil {
+			if err := c.Start(startCtx); err != nil {
+				return components[:i], fmt.Errorf("%s: %w", c.Name, err)
+			}
 		}
 
-		if errors.Is(err, http.ErrServerClosed) {
-			return
-		}
+		a.log.Debug("component started", zap.String("component", c.Name))
+	}
+
+	a.log.Info("app started", zap.Int("components", len(components)), zap.Duration("took", time.Since(begin)))
 
-		select {
-		case <-ctx.Done():
-		case errCh <- fmt.Errorf("http server: %w", err):
+	return components, nil
+}
+
+// run blocks until ctx is done or the first component returns from Run, in
+// which case the rest are cancelled. Apps without runnable components, like
+// setup, return at once.
+func (a *App) run(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup, components []Component) error {
+	errCh := make(chan error, len(components))
+	running := 0
+
+	for _, c := range components {
+		if c.Run == nil {
+			continue
 		}
-	}()
+
+		running++
+		wg.Add(1)
+
+		go func(c Component) {
+			defer wg.Done()
+
+			err := c.Run(ctx)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", c.Name, err)
+			}
+
+			a.log.Debug("component finished", zap.String("component", c.Name), zap.Error(err))
+
+			errCh <- err
+		}(c)
+	}
+
+	if running == 0 {
+		return nil
+	}
 
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil
 	case err := <-errCh:
+		cancel()
+
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+
 		return err
 	}
 }
 
-func (a *App) shutdown(ctx context.Context) error {
-	shutdownErrs := make([]error, 0, 2)
+func (a *App) stop(ctx context.Context, components []Component, wg *sync.WaitGroup) error {
+	a.log.Info("app shutting down")
+
+	stopErrs := make([]error, 0, len(components))
 
-	if err := a.server.Shutdown(ctx); err != nil {
-		shutdownErrs = append(shutdownErrs, fmt.Errorf("shutdown server: %w", err))
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		if err := c.Stop(ctx); err != nil {
+			stopErrs = append(stopErrs, fmt.Errorf("%s: %w", c.Name, err))
+		}
+
+		a.log.Debug("component stopped", zap.String("component", c.Name))
 	}
 
-	if err := a.mongoClient.Disconnect(ctx); err != nil {
-		shutdownErrs = append(shutdownErrs, fmt.Errorf("mongo disconnect: %w", err))
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		stopErrs = append(stopErrs, fmt.Errorf("wait for running components: %w", ctx.Err()))
 	}
 
-	return errors.Join(shutdownErrs...)
+	return errors.Join(stopErrs...)
 }
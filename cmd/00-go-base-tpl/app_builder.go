@@ -2,21 +2,58 @@ package main
 
 import (
 	"00-go-base-tpl-sv/cmd/00-go-base-tpl/handler"
+	"00-go-base-tpl-sv/internal/events"
+	"00-go-base-tpl-sv/internal/frontend"
+	"00-go-base-tpl-sv/internal/health"
+	"00-go-base-tpl-sv/internal/i18n"
+	"00-go-base-tpl-sv/internal/metrics"
+	"00-go-base-tpl-sv/internal/migration"
 	"00-go-base-tpl-sv/internal/player"
+	"00-go-base-tpl-sv/internal/quiz"
+	"00-go-base-tpl-sv/internal/telegram"
+	"00-go-base-tpl-sv/internal/tracing"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/bytedance/sonic"
 	"github.com/gorilla/mux"
+	"github.com/grafana/pyroscope-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
 type AppBuilder struct {
-	config         *Config
-	log            *zap.Logger
-	serverListener net.Listener
+	config              *Config
+	log                 *zap.Logger
+	serverListener      net.Listener
+	adminServerListener net.Listener
+	mongoClient         *mongo.Client
+	rmqConn             *events.Conn
+	migrator            *migration.Migrator
+
+	metricsRegistry   *prometheus.Registry
+	metricsRegisterer prometheus.Registerer
+	quizMetrics       *metrics.Quiz
+	amqpMetrics       *metrics.AMQP
+
+	tracerProvider *sdktrace.TracerProvider
 }
 
 func NewAppBuilder() *AppBuilder {
@@ -41,55 +78,442 @@ func (a *AppBuilder) SetServerListener(listener net.Listener) *AppBuilder {
 	return a
 }
 
+func (a *AppBuilder) SetAdminServerListener(listener net.Listener) *AppBuilder {
+	a.adminServerListener = listener
+
+	return a
+}
+
 func (a *AppBuilder) Build() (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	if a.serverListener == nil {
+		listener, err := net.Listen("tcp", a.config.HTTP.Listen)
+		if err != nil {
+			return nil, fmt.Errorf("create server listener: %w", err)
+		}
+
+		a.serverListener = listener
+	}
+
+	if a.adminServerListener == nil {
+		listener, err := net.Listen("tcp", a.config.HTTP.AdminListen)
+		if err != nil {
+			return nil, fmt.Errorf("create admin server listener: %w", err)
+		}
+
+		a.adminServerListener = listener
+	}
+
+	return a.createApp()
+}
+
+func (a *AppBuilder) BuildSetup() (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	app := a.createBaseApp()
+	app.Register(runnerComponent("migrations", func(ctx context.Context) error {
+		done, err := a.migrator.Up(ctx, false)
+
+		for _, st := range done {
+			app.log.Info("migration applied", zap.Uint64("version", st.Version), zap.String("name", st.Name))
+		}
+
+		return err
+	}))
+
+	return app, nil
+}
+
+// BuildMigrate runs migrations explicitly, so unlike other apps it doesn't
+// check them on boot: status and dry-run must work with pending migrations.
+func (a *AppBuilder) BuildMigrate(up, dryRun bool, out io.Writer) (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	app := a.createBaseApp()
+	app.Register(runnerComponent("migrate", func(ctx context.Context) error {
+		var (
+			statuses []migration.Status
+			err      error
+		)
+
+		if up {
+			statuses, err = a.migrator.Up(ctx, dryRun)
+		} else {
+			statuses, err = a.migrator.Status(ctx)
+		}
+
+		writeMigrationStatuses(out, statuses)
+
+		return err
+	}))
+
+	return app, nil
+}
+
+// BuildWorker connects to RabbitMQ, workers consume events. Crons don't.
+func (a *AppBuilder) BuildWorker(name string) (*App, error) {
+	app, err := a.buildRunner("worker", name, a.createWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	app.Register(rabbitmqComponent(a.rmqConn, app.log))
+
+	return app, nil
+}
+
+func (a *AppBuilder) BuildCron(name string) (*App, error) {
+	return a.buildRunner("cron", name, a.createCrons)
+}
+
+func (a *AppBuilder) BuildPlayerImport(r io.Reader, format handler.ImportFormat, ordered bool, out io.Writer) (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	importer := handler.NewPlayerImporter(a.createPlayerService(a.createPlayerStorage()))
+
+	app := a.createBaseApp(SetupperFunc(a.migrator.Check))
+	app.Register(runnerComponent("import-players", func(ctx context.Context) error {
+		report, err := importer.Import(ctx, r, format, ordered)
+		if err != nil {
+			return fmt.Errorf("import players: %w", err)
+		}
+
+		data, err := sonic.ConfigFastest.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report: %w", err)
+		}
+
+		_, err = fmt.Fprintln(out, string(data))
+
+		return err
+	}))
+
+	return app, nil
+}
+
+// BuildQuestionImport imports as no user, so any admin may approve the
+// submitted revisions.
+func (a *AppBuilder) BuildQuestionImport(r io.Reader, format handler.QuestionFormat, dryRun bool, out io.Writer) (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	importer := handler.NewQuestionImporter(a.createAuthoringService())
+
+	app := a.createBaseApp(SetupperFunc(a.migrator.Check))
+	app.Register(runnerComponent("import-questions", func(ctx context.Context) error {
+		report, err := importer.Import(ctx, r, format, 0, dryRun)
+		if err != nil {
+			return fmt.Errorf("import questions: %w", err)
+		}
+
+		data, err := sonic.ConfigFastest.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report: %w", err)
+		}
+
+		_, err = fmt.Fprintln(out, string(data))
+
+		return err
+	}))
+
+	return app, nil
+}
+
+func (a *AppBuilder) BuildQuestionExport(w io.Writer, format handler.QuestionFormat, filter quiz.QuestionFilter) (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	importer := handler.NewQuestionImporter(a.createAuthoringService())
+
+	app := a.createBaseApp(SetupperFunc(a.migrator.Check))
+	app.Register(runnerComponent("export-questions", func(ctx context.Context) error {
+		if err := importer.Export(ctx, w, format, filter); err != nil {
+			return fmt.Errorf("export questions: %w", err)
+		}
+
+		return nil
+	}))
+
+	return app, nil
+}
+
+func (a *AppBuilder) buildRunner(
+	kind string,
+	name string,
+	create func(playerSv player.Service) map[string]Runner,
+) (*App, error) {
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	runners := create(a.createPlayerService(a.createPlayerStorage()))
+
+	runner, ok := runners[name]
+	if !ok {
+		names := make([]string, 0, len(runners))
+		for n := range runners {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown %s %q, available: [%s]", kind, name, strings.Join(names, ", "))
+	}
+
+	app := a.createBaseApp(SetupperFunc(a.migrator.Check))
+	app.Register(runnerComponent(kind+" "+name, runner))
+
+	return app, nil
+}
+
+func (a *AppBuilder) prepare() error {
 	if a.config == nil {
-		return nil, errors.New("config must be defined")
+		return errors.New("config must be defined")
 	}
 
 	if a.log == nil {
 		a.log = zap.NewNop()
 	}
 
-	if a.serverListener == nil {
-		listener, err := net.Listen("tcp", a.config.HTTP.Listen)
+	if a.metricsRegistry == nil {
+		a.metricsRegistry, a.metricsRegisterer = metrics.NewRegistry(a.config.App.ServiceName)
+		a.quizMetrics = metrics.NewQuiz(a.metricsRegisterer)
+		a.amqpMetrics = metrics.NewAMQP(a.metricsRegisterer)
+	}
+
+	if a.tracerProvider == nil {
+		provider, err := tracing.NewProvider(context.Background(), tracing.Config{
+			ServiceName: a.config.App.ServiceName,
+			Exporter:    a.config.Tracing.Exporter,
+			Endpoint:    a.config.Tracing.Endpoint,
+			SampleRatio: a.config.Tracing.SampleRatio,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("create server listener: %w", err)
+			return fmt.Errorf("create tracer provider: %w", err)
 		}
 
-		a.serverListener = listener
+		a.tracerProvider = provider
+	}
+
+	if err := a.initMongoClient(); err != nil {
+		return err
+	}
+
+	if a.rmqConn == nil {
+		a.rmqConn = events.NewConn(events.Config{
+			DSN:               a.config.RMQ.DSN,
+			Exchange:          a.config.RMQ.Exchange,
+			FallbackDelay:     a.config.RMQ.FallbackDelay,
+			MaxFailedAttempts: a.config.RMQ.MaxFailedAttempts,
+			Heartbeat:         a.config.RMQ.Heartbeat,
+		})
+	}
+
+	return a.initMigrator()
+}
+
+func (a *AppBuilder) initMigrator() error {
+	if a.migrator != nil {
+		return nil
+	}
+
+	migrator, err := migration.NewMigrator(
+		a.mongoClient.Database(a.config.Mongo.Database),
+		a.config.Mongo.MigrationCollection,
+		append(
+			player.Migrations(a.config.Mongo.PlayerCollection),
+			quiz.Migrations(quiz.Collections{
+				Question:          a.config.Mongo.QuestionCollection,
+				Session:           a.config.Mongo.QuizSessionCollection,
+				Revision:          a.config.Mongo.RevisionCollection,
+				CommunityQuestion: a.config.Mongo.CommunityCollection,
+				CommunityVote:     a.config.Mongo.VoteCollection,
+				Translation:       a.config.Mongo.TranslationCollection,
+			})...,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("create migrator: %w", err)
 	}
 
-	return a.createApp(), nil
+	a.migrator = migrator
+
+	return nil
+}
+
+func (a *AppBuilder) initMongoClient() error {
+	if a.mongoClient != nil {
+		return nil
+	}
+
+	mongoMetrics := metrics.NewMongo(a.metricsRegisterer)
+
+	client, err := mongo.NewClient(
+		options.Client().
+			ApplyURI(a.config.Mongo.DSN).
+			SetMonitor(chainCommandMonitors(
+				otelmongo.NewMonitor(otelmongo.WithTracerProvider(a.tracerProvider)),
+				mongoMetrics.CommandMonitor(),
+			)).
+			SetPoolMonitor(mongoMetrics.PoolMonitor()),
+	)
+	if err != nil {
+		return fmt.Errorf("create mongo client: %w", err)
+	}
+
+	a.mongoClient = client
+
+	return nil
+}
+
+// createBaseApp registers the components every app needs: tracing, mongo and
+// setup, which runners and servers depend on.
+func (a *AppBuilder) createBaseApp(setuppers ...Setupper) *App {
+	app := &App{
+		log: a.log.Named("app"),
+		//
+		startupTimeout:  a.config.App.StartupTimeout,
+		shutdownTimeout: a.config.App.ShutdownTimeout,
+	}
+
+	app.Register(
+		tracingComponent(a.tracerProvider),
+		mongoComponent(a.mongoClient, app.log),
+		setupComponent(setuppers),
+	)
+
+	return app
 }
 
-func (a *AppBuilder) createApp() *App {
+func (a *AppBuilder) createApp() (*App, error) {
 	var (
 		playerStorage = a.createPlayerStorage()
 	)
 
+	webApp, err := a.createFrontend()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := i18n.NewCatalog(a.config.I18n.DefaultLocale, a.config.I18n.Fallbacks)
+	if err != nil {
+		return nil, fmt.Errorf("create message catalog: %w", err)
+	}
+
+	var (
+		playerSv = a.createPlayerService(playerStorage)
+		quizSv   = a.createQuizService()
+		authorSv = a.createAuthoringService()
+		commSv   = a.createCommunityService(authorSv)
+		transSv  = a.createTranslationService()
+	)
+
+	auth, err := a.createTelegramAuth()
+	if err != nil {
+		return nil, err
+	}
+
 	var (
-		playerSv      = a.createPlayerService(playerStorage)
-		playerHandler = handler.NewPlayers(playerSv, a.log)
+		playerHandler = handler.NewPlayers(
+			playerSv,
+			handler.NewPlayerImporter(playerSv),
+			a.config.App.TelegramBotToken,
+			a.log.Named("players"),
+		)
+		quizHandler   = handler.NewQuiz(quizSv, transSv, auth, a.log.Named("quiz"))
+		webAppHandler = handler.NewWebApp(
+			webApp,
+			auth,
+			quizSv,
+			transSv,
+			catalog,
+			a.config.App.Features,
+			a.log.Named("webapp"),
+		)
+		questionHandler = handler.NewQuestions(
+			authorSv,
+			handler.NewQuestionImporter(authorSv),
+			auth,
+			a.log.Named("questions"),
+		)
+		communityHandler   = handler.NewCommunity(commSv, auth, a.log.Named("community"))
+		translationHandler = handler.NewTranslations(transSv, auth, a.log.Named("translations"))
 	)
 
 	var (
 		router = mux.NewRouter()
 		server = a.createHTTPServer(router)
+
+		adminRouter = mux.NewRouter()
+		adminServer = a.createAdminHTTPServer(adminRouter)
+
+		healthChecks = a.createHealth()
 	)
 
-	a.registerHTTPHandlers(router, playerHandler)
+	a.registerHTTPHandlers(
+		router,
+		catalog,
+		playerHandler,
+		quizHandler,
+		webAppHandler,
+		questionHandler,
+		communityHandler,
+		translationHandler,
+	)
+	a.registerAdminHTTPHandlers(adminRouter, healthChecks)
 
-	return &App{
-		//log: a.log.Named("app"),
-		//
-		startupTimeout:  a.config.App.StartupTimeout,
-		shutdownTimeout: a.config.App.ShutdownTimeout,
-		//
-		setuppers: []Setupper{playerStorage},
-		//
-		server:         server,
-		serverListener: a.serverListener,
+	app := a.createBaseApp(SetupperFunc(a.migrator.Check))
+
+	if config := a.createPyroscopeConfig(); config != nil {
+		app.Register(profilerComponent(*config, app.log))
 	}
+
+	app.Register(
+		rabbitmqComponent(a.rmqConn, app.log),
+		httpServerComponent("admin http server", adminServer, a.adminServerListener, app.log, "mongo"),
+		httpServerComponent("http server", server, a.serverListener, app.log, "setup", "rabbitmq"),
+		healthComponent(healthChecks, a.config.App.ShutdownDrainDelay, "admin http server", "http server"),
+	)
+
+	return app, nil
+}
+
+// createWorkers lists long-running processes started with "worker <name>",
+// each deployed as a Helm daemon. Consumers read a queue of their own named
+// after the worker.
+func (a *AppBuilder) createWorkers(_ player.Service) map[string]Runner {
+	consumer := events.NewConsumer(a.rmqConn, a.amqpMetrics)
+
+	return map[string]Runner{
+		"quiz-sessions": func(ctx context.Context) error {
+			return consumer.Consume(
+				ctx,
+				a.queueName("quiz-sessions"),
+				events.RoutingSessionFinished,
+				events.LogSessionFinished(a.log.Named("quiz-sessions")),
+			)
+		},
+	}
+}
+
+func (a *AppBuilder) queueName(worker string) string {
+	return a.config.RMQ.Exchange + ".queue." + worker
+}
+
+// createCrons lists one-shot jobs started with "cron <name>" by Helm cron jobs.
+func (a *AppBuilder) createCrons(_ player.Service) map[string]Runner {
+	return map[string]Runner{}
 }
 
 func (a *AppBuilder) createHTTPServer(h http.Handler) *http.Server {
@@ -100,20 +524,241 @@ func (a *AppBuilder) createHTTPServer(h http.Handler) *http.Server {
 	}
 }
 
+// createAdminHTTPServer allows long writes for /debug/pprof/profile?seconds=N.
+func (a *AppBuilder) createAdminHTTPServer(h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:      h,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 2 * time.Minute,
+	}
+}
+
+// createHealth checks Mongo and the RabbitMQ connection. There is no bot
+// worker yet; it adds its check with AddReadinessCheck when it lands.
+func (a *AppBuilder) createHealth() *health.Health {
+	h := health.New(2 * time.Second)
+
+	h.AddReadinessCheck("mongo", func(ctx context.Context) error {
+		return a.mongoClient.Ping(ctx, readpref.Primary())
+	})
+	h.AddReadinessCheck("rabbitmq", a.rmqConn.Check)
+
+	return h
+}
+
+func (a *AppBuilder) createPyroscopeConfig() *pyroscope.Config {
+	if a.config.App.PyroscopeDSN == "" {
+		return nil
+	}
+
+	pod := os.Getenv("HOSTNAME")
+	if pod == "" {
+		pod, _ = os.Hostname() // nolint tag is informational only
+	}
+
+	return &pyroscope.Config{
+		ApplicationName: a.config.App.ServiceName,
+		ServerAddress:   a.config.App.PyroscopeDSN,
+		Logger:          a.log.Named("pyroscope").Sugar(),
+		Tags: map[string]string{
+			"service": a.config.App.ServiceName,
+			"pod":     pod,
+		},
+	}
+}
+
+func (a *AppBuilder) createFrontend() (*frontend.Frontend, error) {
+	fsys := frontend.Embedded()
+	if a.config.Frontend.Dev {
+		fsys = os.DirFS(a.config.Frontend.Dir)
+	}
+
+	f, err := frontend.New(fsys, a.config.Frontend.Dev)
+	if err != nil {
+		return nil, fmt.Errorf("create frontend: %w", err)
+	}
+
+	return f, nil
+}
+
 func (a *AppBuilder) createPlayerStorage() *player.StorageMongo {
-	return player.NewStorageMongo()
+	return player.NewStorageMongo(
+		a.mongoClient.Database(a.config.Mongo.Database).Collection(a.config.Mongo.PlayerCollection),
+	)
 }
 
 func (a *AppBuilder) createPlayerService(storage player.Storage) player.Service {
-	return player.NewService(
-		a.config.App.ServiceName,
-		storage,
+	return player.NewTracedService(
+		player.NewService(
+			a.config.App.ServiceName,
+			storage,
+		),
+		a.tracerProvider.Tracer("00-go-base-tpl-sv/player"),
+	)
+}
+
+func (a *AppBuilder) createQuizService() quiz.Service {
+	db := a.mongoClient.Database(a.config.Mongo.Database)
+
+	return quiz.NewTracedService(
+		quiz.NewService(
+			quiz.NewQuestionStorageMongo(db.Collection(a.config.Mongo.QuestionCollection)),
+			quiz.NewSessionStorageMongo(db.Collection(a.config.Mongo.QuizSessionCollection)),
+			a.quizMetrics,
+			events.NewQuizEvents(events.NewPublisher(a.rmqConn, a.amqpMetrics), a.log.Named("events")),
+			quiz.Scoring{Strategies: quiz.ScoringStrategies(), Modes: a.config.Quiz.Scoring},
+			a.config.Quiz.QuestionsPerSession,
+			a.config.Quiz.QuestionTimeLimit,
+			a.config.Quiz.RecentWindow,
+		),
+		a.tracerProvider.Tracer("00-go-base-tpl-sv/quiz"),
 	)
 }
 
+func (a *AppBuilder) createAuthoringService() quiz.AuthoringService {
+	db := a.mongoClient.Database(a.config.Mongo.Database)
+
+	return quiz.NewTracedAuthoringService(
+		quiz.NewAuthoringService(
+			quiz.NewQuestionStorageMongo(db.Collection(a.config.Mongo.QuestionCollection)),
+			quiz.NewRevisionStorageMongo(db.Collection(a.config.Mongo.RevisionCollection)),
+		),
+		a.tracerProvider.Tracer("00-go-base-tpl-sv/quiz"),
+	)
+}
+
+func (a *AppBuilder) createCommunityService(authoring quiz.AuthoringService) quiz.CommunityService {
+	db := a.mongoClient.Database(a.config.Mongo.Database)
+
+	return quiz.NewTracedCommunityService(
+		quiz.NewCommunityService(
+			authoring,
+			quiz.NewCommunityStorageMongo(
+				db.Collection(a.config.Mongo.CommunityCollection),
+				db.Collection(a.config.Mongo.VoteCollection),
+			),
+			quiz.CommunityConfig{
+				SubmissionsPerDay: a.config.Quiz.CommunitySubmissionsPerDay,
+				PromoteScore:      a.config.Quiz.CommunityPromoteScore,
+				DemoteReports:     a.config.Quiz.CommunityDemoteReports,
+			},
+		),
+		a.tracerProvider.Tracer("00-go-base-tpl-sv/quiz"),
+	)
+}
+
+func (a *AppBuilder) createTranslationService() quiz.TranslationService {
+	db := a.mongoClient.Database(a.config.Mongo.Database)
+
+	return quiz.NewTracedTranslationService(
+		quiz.NewTranslationService(
+			quiz.NewQuestionStorageMongo(db.Collection(a.config.Mongo.QuestionCollection)),
+			quiz.NewTranslationStorageMongo(db.Collection(a.config.Mongo.TranslationCollection)),
+		),
+		a.tracerProvider.Tracer("00-go-base-tpl-sv/quiz"),
+	)
+}
+
+// createTelegramAuth signs nonces with the bot token and lets them live as
+// long as the init data they were issued for.
+func (a *AppBuilder) createTelegramAuth() (*handler.TelegramAuth, error) {
+	verifier, err := telegram.NewVerifier(a.config.App.TelegramBotToken, a.config.App.InitDataMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("create init data verifier: %w", err)
+	}
+
+	auth, err := handler.NewTelegramAuth(
+		verifier,
+		a.config.App.TelegramBotToken,
+		a.config.App.InitDataMaxAge,
+		a.config.App.AdminTelegramIDs,
+		a.quizMetrics,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create telegram auth: %w", err)
+	}
+
+	return auth, nil
+}
+
 func (a *AppBuilder) registerHTTPHandlers(
 	router *mux.Router,
+	catalog *i18n.Catalog,
 	playerHandler *handler.Players,
+	quizHandler *handler.Quiz,
+	webAppHandler *handler.WebApp,
+	questionHandler *handler.Questions,
+	communityHandler *handler.Community,
+	translationHandler *handler.Translations,
 ) {
+	router.Use(
+		otelmux.Middleware(a.config.App.ServiceName, otelmux.WithTracerProvider(a.tracerProvider)),
+		handler.RequestID,
+		handler.Logging(a.log.Named("http")),
+		handler.Metrics(metrics.NewHTTP(a.metricsRegisterer)),
+		handler.Localization(catalog),
+	)
+
+	webAppHandler.Register(router)
+	quizHandler.Register(router)
+	questionHandler.Register(router)
+	communityHandler.Register(router)
+	translationHandler.Register(router)
 	playerHandler.Register(router)
 }
+
+func (a *AppBuilder) registerAdminHTTPHandlers(router *mux.Router, healthChecks *health.Health) {
+	router.HandleFunc("/healthz", healthChecks.Liveness).Name("healthz").Methods("GET")
+	router.HandleFunc("/readyz", healthChecks.Readiness).Name("readyz").Methods("GET")
+	router.HandleFunc("/startupz", healthChecks.Startup).Name("startupz").Methods("GET")
+
+	router.Handle(
+		"/metrics",
+		promhttp.HandlerFor(a.metricsRegistry, promhttp.HandlerOpts{Registry: a.metricsRegisterer}),
+	).Name("metrics").Methods("GET")
+
+	if a.config.App.PProf {
+		router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline).Name("pprof_cmdline")
+		router.HandleFunc("/debug/pprof/profile", pprof.Profile).Name("pprof_profile")
+		router.HandleFunc("/debug/pprof/symbol", pprof.Symbol).Name("pprof_symbol")
+		router.HandleFunc("/debug/pprof/trace", pprof.Trace).Name("pprof_trace")
+		router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index).Name("pprof_index")
+	}
+}
+
+func writeMigrationStatuses(out io.Writer, statuses []migration.Status) {
+	for _, st := range statuses {
+		state := "pending"
+		if st.Applied() {
+			state = "applied " + st.AppliedAt.UTC().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(out, "%d\t%s\t%s\n", st.Version, st.Name, state) // nolint
+	}
+}
+
+func chainCommandMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"00-go-base-tpl-sv/cmd/00-go-base-tpl/handler"
+	"00-go-base-tpl-sv/internal/quiz"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultCommand = "serve"
+
+var errUsage = errors.New("usage")
+
+type command struct {
+	name  string
+	usage string
+	short string
+	run   func(ctx context.Context, b *AppBuilder, args []string) error
+	// serves is set for commands listening for HTTP, they verify WebApp
+	// users with the bot token.
+	serves bool
+}
+
+func commands() []command {
+	return []command{
+		{
+			name:   "serve",
+			usage:  "serve",
+			short:  "start the HTTP server (default)",
+			run:    runServe,
+			serves: true,
+		},
+		{
+			name:  "setup",
+			usage: "setup",
+			short: "apply pending migrations and exit",
+			run:   runSetup,
+		},
+		{
+			name:  "migrate",
+			usage: "migrate [up|status] [--dry-run]",
+			short: "apply or list migrations, dry-run only lists pending ones",
+			run:   runMigrate,
+		},
+		{
+			name:  "worker",
+			usage: "worker <name>",
+			short: "run a long-living worker",
+			run:   runWorker,
+		},
+		{
+			name:  "cron",
+			usage: "cron <name>",
+			short: "run a scheduled job once",
+			run:   runCron,
+		},
+		{
+			name:  "config",
+			usage: "config print",
+			short: "print the effective config with secrets redacted",
+			run:   runConfig,
+		},
+		{
+			name:  "import-players",
+			usage: "import-players [--format csv|ndjson] [--ordered] <file>",
+			short: "import players from a file",
+			run:   runImportPlayers,
+		},
+		{
+			name:  "import-questions",
+			usage: "import-questions [--format json|csv|yaml] [--dry-run] <file>",
+			short: "import questions for review, upserting by external_id",
+			run:   runImportQuestions,
+		},
+		{
+			name:  "export-questions",
+			usage: "export-questions [--format json|csv|yaml] [flags] [<file>]",
+			short: "export questions to a file or stdout",
+			run:   runExportQuestions,
+		},
+	}
+}
+
+// commandName returns the command args start with, the default one if none.
+func commandName(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+
+	return defaultCommand
+}
+
+func commandServes(name string) bool {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.serves
+		}
+	}
+
+	return false
+}
+
+func RunCommand(ctx context.Context, config *Config, log *zap.Logger, args []string) error {
+	name := commandName(args)
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name != name {
+			continue
+		}
+
+		log.Debug("run command", zap.String("command", name), zap.Strings("args", args))
+
+		err := cmd.run(ctx, NewAppBuilder().SetConfig(config).SetLog(log.Named(name)), args)
+		if errors.Is(err, errUsage) {
+			return fmt.Errorf("%w: %s", err, cmd.usage)
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("unknown command %q\n%s", name, Usage())
+}
+
+func Usage() string {
+	lines := make([]string, 0, len(commands())+1)
+	lines = append(lines, "commands:")
+
+	for _, cmd := range commands() {
+		lines = append(lines, fmt.Sprintf("  %-58s %s", cmd.usage, cmd.short))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func runServe(ctx context.Context, b *AppBuilder, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	return buildAndRun(ctx, b.Build)
+}
+
+func runSetup(ctx context.Context, b *AppBuilder, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	return buildAndRun(ctx, b.BuildSetup)
+}
+
+func runWorker(ctx context.Context, b *AppBuilder, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return buildAndRun(ctx, func() (*App, error) {
+		return b.BuildWorker(args[0])
+	})
+}
+
+func runCron(ctx context.Context, b *AppBuilder, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return buildAndRun(ctx, func() (*App, error) {
+		return b.BuildCron(args[0])
+	})
+}
+
+func runMigrate(ctx context.Context, b *AppBuilder, args []string) error {
+	fs := pflag.NewFlagSet("migrate", pflag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "Only list pending migrations")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	var up bool
+
+	switch fs.Arg(0) {
+	case "", "up":
+		up = true
+	case "status":
+	default:
+		return errUsage
+	}
+
+	if fs.NArg() > 1 || (!up && *dryRun) {
+		return errUsage
+	}
+
+	return buildAndRun(ctx, func() (*App, error) {
+		return b.BuildMigrate(up, *dryRun, os.Stdout)
+	})
+}
+
+func runImportPlayers(ctx context.Context, b *AppBuilder, args []string) error {
+	fs := pflag.NewFlagSet("import-players", pflag.ContinueOnError)
+	formatName := fs.String("format", "", "Input format: csv or ndjson, taken from the file extension by default")
+	ordered := fs.Bool("ordered", false, "Stop inserting after the first failed row")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	path := fs.Arg(0)
+
+	if *formatName == "" {
+		*formatName = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	format, err := handler.ParseImportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open import file: %w", err)
+	}
+
+	defer f.Close() // nolint
+
+	return buildAndRun(ctx, func() (*App, error) {
+		return b.BuildPlayerImport(f, format, *ordered, os.Stdout)
+	})
+}
+
+func runImportQuestions(ctx context.Context, b *AppBuilder, args []string) error {
+	fs := pflag.NewFlagSet("import-questions", pflag.ContinueOnError)
+	formatName := fs.String("format", "", "Input format: json, csv or yaml, taken from the file extension by default")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be created or updated")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	path := fs.Arg(0)
+
+	if *formatName == "" {
+		*formatName = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	format, err := handler.ParseQuestionFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open import file: %w", err)
+	}
+
+	defer f.Close() // nolint
+
+	return buildAndRun(ctx, func() (*App, error) {
+		return b.BuildQuestionImport(f, format, *dryRun, os.Stdout)
+	})
+}
+
+func runExportQuestions(ctx context.Context, b *AppBuilder, args []string) error {
+	fs := pflag.NewFlagSet("export-questions", pflag.ContinueOnError)
+	formatName := fs.String("format", "", "Output format: json, csv or yaml, taken from the file extension or json")
+	category := fs.String("category", "", "Only export questions of the category")
+	language := fs.String("language", "", "Only export questions in the language")
+	retired := fs.Bool("retired", false, "Export retired questions instead of published ones")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+
+	path := fs.Arg(0)
+
+	if *formatName == "" {
+		*formatName = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	if *formatName == "" {
+		*formatName = string(handler.QuestionFormatJSON)
+	}
+
+	format, err := handler.ParseQuestionFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	filter := quiz.QuestionFilter{Status: quiz.QuestionPublished, Category: *category, Language: *language}
+	if *retired {
+		filter.Status = quiz.QuestionRetired
+	}
+
+	if path == "" {
+		return buildAndRun(ctx, func() (*App, error) {
+			return b.BuildQuestionExport(os.Stdout, format, filter)
+		})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+
+	err = buildAndRun(ctx, func() (*App, error) {
+		return b.BuildQuestionExport(f, format, filter)
+	})
+
+	return errors.Join(err, f.Close())
+}
+
+func runConfig(_ context.Context, b *AppBuilder, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errUsage
+	}
+
+	data, err := yaml.Marshal(b.config.Redacted())
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+
+	_, err = os.Stdout.Write(data)
+
+	return err
+}
+
+func buildAndRun(ctx context.Context, build func() (*App, error)) error {
+	app, err := build()
+	if err != nil {
+		return fmt.Errorf("app build: %w", err)
+	}
+
+	if err := app.Run(ctx); err != nil {
+		return fmt.Errorf("app run: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"00-go-base-tpl-sv/internal/events"
+	"00-go-base-tpl-sv/internal/health"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/pyroscope-go"
+	"go.mongodb.org/mongo-driver/mongo"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+// Component is a part of the app with its own lifecycle. Every hook is
+// optional:
+//   - Start prepares the component and must return within the startup timeout;
+//   - Run blocks while the component works, returning ends the whole app;
+//   - Stop releases the component and is called only if Start succeeded.
+//
+// Components start after everything listed in DependsOn and stop before it.
+type Component struct {
+	Name      string
+	DependsOn []string
+
+	Start func(ctx context.Context) error
+	Run   func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// sortComponents orders components so dependencies come first, keeping the
+// registration order between independent ones.
+func sortComponents(components []Component) ([]Component, error) {
+	index := make(map[string]int, len(components))
+
+	for i, c := range components {
+		if _, ok := index[c.Name]; ok {
+			return nil, fmt.Errorf("component %q registered twice", c.Name)
+		}
+
+		index[c.Name] = i
+	}
+
+	for _, c := range components {
+		for _, dep := range c.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("component %q depends on unknown %q", c.Name, dep)
+			}
+		}
+	}
+
+	var (
+		ordered = make([]Component, 0, len(components))
+		done    = make([]bool, len(components))
+	)
+
+	for len(ordered) < len(components) {
+		progressed := false
+
+	next:
+		for i, c := range components {
+			if done[i] {
+				continue
+			}
+
+			for _, dep := range c.DependsOn {
+				if !done[index[dep]] {
+					continue next
+				}
+			}
+
+			done[i] = true
+			ordered = append(ordered, c)
+			progressed = true
+		}
+
+		if !progressed {
+			pending := make([]string, 0, len(components)-len(ordered))
+			for i, c := range components {
+				if !done[i] {
+					pending = append(pending, c.Name)
+				}
+			}
+
+			return nil, fmt.Errorf("dependency cycle between [%s]", strings.Join(pending, ", "))
+		}
+	}
+
+	return ordered, nil
+}
+
+func tracingComponent(provider *sdktrace.TracerProvider) Component {
+	return Component{
+		Name: "tracing",
+		Stop: provider.Shutdown,
+	}
+}
+
+func mongoComponent(client *mongo.Client, log *zap.Logger) Component {
+	return Component{
+		Name:      "mongo",
+		DependsOn: []string{"tracing"},
+		Start: func(ctx context.Context) error {
+			if err := client.Connect(ctx); err != nil {
+				return fmt.Errorf("connect: %w", err)
+			}
+
+			if err := client.Ping(ctx, nil); err != nil {
+				return fmt.Errorf("ping: %w", err)
+			}
+
+			log.Debug("mongo connected")
+
+			return nil
+		},
+		Stop: client.Disconnect,
+	}
+}
+
+func rabbitmqComponent(conn *events.Conn, log *zap.Logger) Component {
+	return Component{
+		Name:      "rabbitmq",
+		DependsOn: []string{"tracing"},
+		Start: func(ctx context.Context) error {
+			if err := conn.Connect(ctx); err != nil {
+				return fmt.Errorf("connect: %w", err)
+			}
+
+			log.Debug("rabbitmq connected")
+
+			return nil
+		},
+		Stop: conn.Close,
+	}
+}
+
+func setupComponent(setuppers []Setupper) Component {
+	return Component{
+		Name:      "setup",
+		DependsOn: []string{"mongo"},
+		Start: func(ctx context.Context) error {
+			for i, setupper := range setuppers {
+				if err := setupper.Setup(ctx); err != nil {
+					return fmt.Errorf("setup[%d]: %w", i, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func profilerComponent(config pyroscope.Config, log *zap.Logger) Component {
+	var profiler *pyroscope.Profiler
+
+	return Component{
+		Name: "profiler",
+		Start: func(context.Context) error {
+			p, err := pyroscope.Start(config)
+			if err != nil {
+				return err
+			}
+
+			profiler = p
+			log.Info("pyroscope profiler started", zap.String("dsn", config.ServerAddress))
+
+			return nil
+		},
+		Stop: func(context.Context) error {
+			return profiler.Stop()
+		},
+	}
+}
+
+func httpServerComponent(
+	name string,
+	server *http.Server,
+	listener net.Listener,
+	log *zap.Logger,
+	dependsOn ...string,
+) Component {
+	return Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Run: func(context.Context) error {
+			log.Info(name+" started", zap.String("addr", listener.Addr().String()))
+
+			err := server.Serve(listener)
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+
+			return err
+		},
+		Stop: server.Shutdown,
+	}
+}
+
+// healthComponent reports the app started and ready once its dependencies
+// are up. Being stopped first, it drops readiness and waits drainDelay so the
+// load balancer stops routing before the servers shut down.
+func healthComponent(h *health.Health, drainDelay time.Duration, dependsOn ...string) Component {
+	return Component{
+		Name:      "health",
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			h.SetStarted()
+			h.SetReady(true)
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			h.SetReady(false)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(drainDelay):
+			}
+
+			return nil
+		},
+	}
+}
+
+func runnerComponent(name string, runner Runner) Component {
+	return Component{
+		Name:      name,
+		DependsOn: []string{"setup"},
+		Run:       runner,
+	}
+}
@@ -1,6 +1,13 @@
 package main
 
 import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"reflect"
 	"strings"
 	"time"
 
@@ -10,76 +17,382 @@ import (
 )
 
 type appConfig struct {
-	Debug        bool   `mapstructure:"debug"`
-	ServiceName  string `mapstructure:"service-name"`
-	PProf        bool   `mapstructure:"pprof"`
-	PyroscopeDSN string `mapstructure:"pyroscope-dsn"`
+	Debug            bool          `mapstructure:"debug"`
+	ServiceName      string        `mapstructure:"service-name"`
+	TelegramBotToken string        `mapstructure:"telegram-bot-token" secret:"true"`
+	InitDataMaxAge   time.Duration `mapstructure:"telegram-init-data-max-age"`
+	Features         []string      `mapstructure:"features"`
+	AdminTelegramIDs []int64       `mapstructure:"admin-telegram-ids"`
+	PProf            bool          `mapstructure:"pprof"`
+	PyroscopeDSN     string        `mapstructure:"pyroscope-dsn" secret:"dsn"`
 
-	StartupTimeout  time.Duration `mapstructure:"startup-timeout"`
-	ShutdownTimeout time.Duration `mapstructure:"shutdown-timeout"`
+	StartupTimeout     time.Duration `mapstructure:"startup-timeout"`
+	ShutdownTimeout    time.Duration `mapstructure:"shutdown-timeout"`
+	ShutdownDrainDelay time.Duration `mapstructure:"shutdown-drain-delay"`
 }
 
 type mongoConfig struct {
-	DSN              string `mapstructure:"mongo-dsn"`
-	Database         string `mapstructure:"mongo-db"`
-	PlayerCollection string `mapstructure:"mongo-player-collection"`
+	DSN                   string `mapstructure:"mongo-dsn" secret:"dsn"`
+	Database              string `mapstructure:"mongo-db"`
+	PlayerCollection      string `mapstructure:"mongo-player-collection"`
+	QuestionCollection    string `mapstructure:"mongo-question-collection"`
+	QuizSessionCollection string `mapstructure:"mongo-quiz-session-collection"`
+	RevisionCollection    string `mapstructure:"mongo-question-revision-collection"`
+	CommunityCollection   string `mapstructure:"mongo-community-question-collection"`
+	VoteCollection        string `mapstructure:"mongo-community-vote-collection"`
+	TranslationCollection string `mapstructure:"mongo-question-translation-collection"`
+	MigrationCollection   string `mapstructure:"mongo-migration-collection"`
 }
 
 type rmqConfig struct {
-	DSN               string        `mapstructure:"rabbitmq-dsn"`
+	DSN               string        `mapstructure:"rabbitmq-dsn" secret:"dsn"`
+	Exchange          string        `mapstructure:"rabbitmq-exchange"`
 	FallbackDelay     time.Duration `mapstructure:"rabbitmq-fallback-delay"`
 	MaxFailedAttempts int           `mapstructure:"rabbitmq-max-failed-attempt"`
 	Heartbeat         time.Duration `mapstructure:"rabbitmq-heartbeat"`
 }
 
+type tracingConfig struct {
+	Exporter    string  `mapstructure:"tracing-exporter"`
+	Endpoint    string  `mapstructure:"tracing-endpoint"`
+	SampleRatio float64 `mapstructure:"tracing-sample-ratio"`
+}
+
 type httpConfig struct {
-	Listen string `mapstructure:"listen"`
+	Listen      string `mapstructure:"listen"`
+	AdminListen string `mapstructure:"admin-listen"`
+}
+
+type quizConfig struct {
+	QuestionsPerSession int           `mapstructure:"quiz-questions-per-session"`
+	QuestionTimeLimit   time.Duration `mapstructure:"quiz-question-time-limit"`
+	RecentWindow        time.Duration `mapstructure:"quiz-recent-window"`
+
+	// Scoring maps game modes to scoring strategies.
+	Scoring map[string]string `mapstructure:"quiz-scoring"`
+
+	CommunitySubmissionsPerDay int `mapstructure:"quiz-community-submissions-per-day"`
+	CommunityPromoteScore      int `mapstructure:"quiz-community-promote-score"`
+	CommunityDemoteReports     int `mapstructure:"quiz-community-demote-reports"`
+}
+
+type i18nConfig struct {
+	DefaultLocale string            `mapstructure:"i18n-default-locale"`
+	Fallbacks     map[string]string `mapstructure:"i18n-fallbacks"`
+}
+
+type frontendConfig struct {
+	Dev bool   `mapstructure:"frontend-dev"`
+	Dir string `mapstructure:"frontend-dir"`
 }
 
 type Config struct {
-	App   appConfig   `mapstructure:",squash"`
-	Mongo mongoConfig `mapstructure:",squash"`
-	RMQ   rmqConfig   `mapstructure:",squash"`
-	HTTP  httpConfig  `mapstructure:",squash"`
+	App      appConfig      `mapstructure:",squash"`
+	Mongo    mongoConfig    `mapstructure:",squash"`
+	RMQ      rmqConfig      `mapstructure:",squash"`
+	HTTP     httpConfig     `mapstructure:",squash"`
+	Tracing  tracingConfig  `mapstructure:",squash"`
+	Frontend frontendConfig `mapstructure:",squash"`
+	Quiz     quizConfig     `mapstructure:",squash"`
+	I18n     i18nConfig     `mapstructure:",squash"`
 }
 
-func ReadConfig() (*Config, error) {
+// ReadConfig parses args (without the program name) into a fresh flag set
+// and viper instance, so it has no global state. Values are taken from flags,
+// env, the --config YAML/TOML file and defaults, in that order. The remaining
+// args are the command and its flags.
+func ReadConfig(name string, args []string, usage io.Writer) (*Config, []string, error) {
 	_ = godotenv.Load() // nolint
 
-	pflag.Bool("debug", false, "Enable debug")
-	pflag.String("service-name", "00-go-base-tpl", "Service name")
-	pflag.String("telegram-bot-token", "", "")
-	pflag.Duration("startup-timeout", 10*time.Second, "Timeout until application should be started")
-	pflag.Duration("shutdown-timeout", 15*time.Second, "Timeout until application should be stopped")
+	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	fs.SetOutput(usage)
 
-	pflag.String("mongo-dsn", "mongodb://127.0.0.1:27017", "Mongo DSN")
-	pflag.String("mongo-db", "00_go_base_tpl", "Mongo database for player") // TODO rename it
-	pflag.String("mongo-player-collection", "player", "Mongo collection name for players")
+	fs.StringP("config", "c", "", "Path to a YAML or TOML config file with the same keys as flags")
 
-	pflag.String("rabbitmq-dsn", "amqp://127.0.0.1:5672//", "RabbitMQ connection DSN")
+	fs.Bool("debug", false, "Enable debug")
+	fs.String("service-name", "00-go-base-tpl", "Service name")
+	fs.String("telegram-bot-token", "", "Telegram bot token, also signs WebApp nonces")
+	fs.Duration("telegram-init-data-max-age", 24*time.Hour, "Max age of WebApp init data, 0 disables the check")
+	fs.StringSlice("features", nil, "Feature flags enabled for the WebApp, comma separated")
+	fs.IntSlice("admin-telegram-ids", nil, "Telegram user IDs with the admin role, comma separated")
+	fs.Duration("startup-timeout", 10*time.Second, "Timeout until application should be started")
+	fs.Duration("shutdown-timeout", 15*time.Second, "Timeout until application should be stopped")
+	fs.Duration("shutdown-drain-delay", 3*time.Second, "Delay between turning readiness off and stopping the HTTP server")
 
-	pflag.Duration("rabbitmq-fallback-delay", time.Second, "RabbitMQ delay before reconnection retry")
-	pflag.Int("rabbitmq-max-failed-attempt", 5, "RabbitMQ max serial connection attempts before fail")
-	pflag.Duration("rabbitmq-heartbeat", 5*time.Second, "RabbitMQ heartbeat duration")
+	fs.String("mongo-dsn", "mongodb://127.0.0.1:27017", "Mongo DSN")
+	fs.String("mongo-db", "", "Mongo database, the service name with underscores instead of dashes when empty")
+	fs.String("mongo-player-collection", "player", "Mongo collection name for players")
+	fs.String("mongo-question-collection", "question", "Mongo collection name for quiz questions")
+	fs.String("mongo-quiz-session-collection", "quiz_session", "Mongo collection name for quiz sessions")
+	fs.String("mongo-question-revision-collection", "question_revision", "Mongo collection name for question revisions")
+	fs.String("mongo-community-question-collection", "community_question", "Mongo collection name for community questions")
+	fs.String("mongo-community-vote-collection", "community_vote", "Mongo collection name for community votes")
+	fs.String("mongo-question-translation-collection", "question_translation", "Mongo collection name for question translations")
+	fs.String("mongo-migration-collection", "migrations", "Mongo collection name for applied migrations")
 
-	pflag.StringP("listen", "l", ":80", "HTTP binding address")
+	fs.String("rabbitmq-dsn", "amqp://127.0.0.1:5672//", "RabbitMQ connection DSN")
+	fs.String("rabbitmq-exchange", "ev-bus", "RabbitMQ topic exchange events are published to")
 
-	pflag.Bool("pprof", false, "Enable pprof profiling")
-	pflag.String("pyroscope-dsn", "http://localhost:4040", "Pyroscope DSN")
+	fs.Duration("rabbitmq-fallback-delay", time.Second, "RabbitMQ delay before reconnection retry")
+	fs.Int("rabbitmq-max-failed-attempt", 5, "RabbitMQ max serial connection attempts before fail")
+	fs.Duration("rabbitmq-heartbeat", 5*time.Second, "RabbitMQ heartbeat duration")
 
-	pflag.Parse()
+	fs.StringP("listen", "l", ":80", "HTTP binding address")
+	fs.String("admin-listen", ":8081", "Admin HTTP binding address for metrics, never expose it publicly")
 
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	fs.Int("quiz-questions-per-session", 5, "Count of questions in a quiz session")
+	fs.Duration("quiz-question-time-limit", 20*time.Second, "Time to answer a quiz question")
+	fs.Duration("quiz-recent-window", 7*24*time.Hour, "How long questions a player saw are avoided in new decks")
+	fs.StringToString(
+		"quiz-scoring",
+		map[string]string{"solo": "timed"},
+		"Scoring strategy of each game mode: classic or timed, with speed, difficulty and streak bonuses",
+	)
+	fs.Int("quiz-community-submissions-per-day", 3, "Questions a player may submit in 24 hours")
+	fs.Int("quiz-community-promote-score", 10, "Upvotes minus downvotes publishing a community question, the negative rejects it")
+	fs.Int("quiz-community-demote-reports", 3, "Reports retiring a community question")
 
-	err := viper.BindPFlags(pflag.CommandLine)
-	if err != nil {
-		return nil, err
+	fs.String("i18n-default-locale", "en", "Locale of messages when the user's language has none")
+	fs.StringToString("i18n-fallbacks", nil, "Locale tried after the user's one, before the default, e.g. be=ru,uk=ru")
+
+	fs.Bool("frontend-dev", false, "Read frontend templates and assets from frontend-dir on every request")
+	fs.String("frontend-dir", "internal/frontend", "Frontend sources dir used in frontend-dev mode")
+
+	fs.String("tracing-exporter", "none", "Tracing exporter: none, stdout or otlp")
+	fs.String("tracing-endpoint", "", "OTLP HTTP endpoint host:port, OTEL_EXPORTER_OTLP_* env is used when empty")
+	fs.Float64("tracing-sample-ratio", 1, "Share of traces to sample when the caller didn't decide")
+
+	fs.Bool("pprof", false, "Enable pprof handlers on the admin listener")
+	fs.String("pyroscope-dsn", "", "Pyroscope DSN, e.g. http://localhost:4040, profiling is off when empty")
+
+	fs.Usage = func() {
+		fmt.Fprintf(usage, "Usage: %s [flags] [command] [command flags]\n\n%s\n\nflags:\n%s", name, Usage(), fs.FlagUsages())
+	}
+
+	// flags after the command name belong to the command
+	fs.SetInterspersed(false)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	v := viper.New()
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+
+	if err := v.BindPFlags(fs); err != nil {
+		return nil, nil, err
+	}
+
+	if path := v.GetString("config"); path != "" {
+		v.SetConfigFile(path)
+
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
 	}
 
 	var config Config
 
-	err = viper.Unmarshal(&config)
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, nil, err
+	}
+
+	if config.Mongo.Database == "" {
+		config.Mongo.Database = strings.ReplaceAll(config.App.ServiceName, "-", "_")
+	}
+
+	return &config, fs.Args(), nil
+}
+
+// Validate reports every invalid value at once rather than the first one.
+// Some values are only required by the given command.
+func (c *Config) Validate(command string) error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.App.ServiceName != "", "service-name", "must not be empty")
+	check(c.App.StartupTimeout > 0, "startup-timeout", "must be positive")
+	check(c.App.ShutdownTimeout > 0, "shutdown-timeout", "must be positive")
+	check(c.App.ShutdownDrainDelay >= 0, "shutdown-drain-delay", "must not be negative")
+	check(c.App.InitDataMaxAge >= 0, "telegram-init-data-max-age", "must not be negative")
+
+	if commandServes(command) {
+		check(c.App.TelegramBotToken != "", "telegram-bot-token", "must not be empty for %s", command)
+	}
+
+	for _, id := range c.App.AdminTelegramIDs {
+		check(id > 0, "admin-telegram-ids", "must be positive user IDs, got %d", id)
+	}
+	check(
+		c.App.ShutdownDrainDelay < c.App.ShutdownTimeout,
+		"shutdown-drain-delay", "must be less than shutdown-timeout %s", c.App.ShutdownTimeout,
+	)
+
+	if c.App.PyroscopeDSN != "" {
+		check(isURL(c.App.PyroscopeDSN, "http", "https"), "pyroscope-dsn", "must be an http(s) URL")
+	}
+
+	check(isURL(c.Mongo.DSN, "mongodb", "mongodb+srv"), "mongo-dsn", "must be a mongodb:// or mongodb+srv:// URL")
+	check(c.Mongo.Database != "", "mongo-db", "must not be empty")
+	check(c.Mongo.PlayerCollection != "", "mongo-player-collection", "must not be empty")
+	check(c.Mongo.MigrationCollection != "", "mongo-migration-collection", "must not be empty")
+	check(c.Mongo.QuestionCollection != "", "mongo-question-collection", "must not be empty")
+	check(c.Mongo.QuizSessionCollection != "", "mongo-quiz-session-collection", "must not be empty")
+	check(c.Mongo.RevisionCollection != "", "mongo-question-revision-collection", "must not be empty")
+	check(c.Mongo.CommunityCollection != "", "mongo-community-question-collection", "must not be empty")
+	check(c.Mongo.VoteCollection != "", "mongo-community-vote-collection", "must not be empty")
+	check(c.Mongo.TranslationCollection != "", "mongo-question-translation-collection", "must not be empty")
+
+	collections := map[string]string{}
+	for _, kv := range [][2]string{
+		{"mongo-player-collection", c.Mongo.PlayerCollection},
+		{"mongo-question-collection", c.Mongo.QuestionCollection},
+		{"mongo-quiz-session-collection", c.Mongo.QuizSessionCollection},
+		{"mongo-question-revision-collection", c.Mongo.RevisionCollection},
+		{"mongo-community-question-collection", c.Mongo.CommunityCollection},
+		{"mongo-community-vote-collection", c.Mongo.VoteCollection},
+		{"mongo-question-translation-collection", c.Mongo.TranslationCollection},
+		{"mongo-migration-collection", c.Mongo.MigrationCollection},
+	} {
+		key, name := kv[0], kv[1]
+		if other, ok := collections[name]; ok && name != "" {
+			check(false, key, "must differ from %s", other)
+		}
+
+		collections[name] = key
+	}
+
+	check(
+		c.Quiz.QuestionsPerSession > 0 && c.Quiz.QuestionsPerSession <= 50,
+		"quiz-questions-per-session", "must be between 1 and 50",
+	)
+	check(c.Quiz.QuestionTimeLimit >= time.Second, "quiz-question-time-limit", "must be at least 1s")
+	check(c.Quiz.RecentWindow >= 0, "quiz-recent-window", "must not be negative")
+
+	modes := make(map[string]bool)
+	for _, mode := range quiz.Modes() {
+		modes[mode] = true
+	}
+
+	strategies := quiz.ScoringStrategies()
+
+	for mode, scoring := range c.Quiz.Scoring {
+		_, known := strategies[scoring]
+
+		check(modes[mode], "quiz-scoring", "unknown mode %q", mode)
+		check(known, "quiz-scoring", "unknown strategy %q for %s", scoring, mode)
+	}
+
+	check(c.Quiz.CommunitySubmissionsPerDay >= 0, "quiz-community-submissions-per-day", "must not be negative")
+	check(c.Quiz.CommunityPromoteScore > 0, "quiz-community-promote-score", "must be positive")
+	check(c.Quiz.CommunityDemoteReports > 0, "quiz-community-demote-reports", "must be positive")
+
+	check(isLocale(c.I18n.DefaultLocale), "i18n-default-locale", "must be a lowercase ISO 639-1 code")
+
+	for from, to := range c.I18n.Fallbacks {
+		check(isLocale(from) && isLocale(to), "i18n-fallbacks", "must map lowercase ISO 639-1 codes, got %s=%s", from, to)
+	}
+
+	check(isURL(c.RMQ.DSN, "amqp", "amqps"), "rabbitmq-dsn", "must be an amqp:// or amqps:// URL")
+	check(c.RMQ.Exchange != "", "rabbitmq-exchange", "must not be empty")
+	check(c.RMQ.FallbackDelay >= 0, "rabbitmq-fallback-delay", "must not be negative")
+	check(c.RMQ.MaxFailedAttempts > 0, "rabbitmq-max-failed-attempt", "must be positive")
+	check(c.RMQ.Heartbeat > 0, "rabbitmq-heartbeat", "must be positive")
+
+	check(isListenAddr(c.HTTP.Listen), "listen", "must be host:port")
+	check(isListenAddr(c.HTTP.AdminListen), "admin-listen", "must be host:port")
+	check(c.HTTP.Listen != c.HTTP.AdminListen, "admin-listen", "must differ from listen")
+
+	if c.Frontend.Dev {
+		check(c.Frontend.Dir != "", "frontend-dir", "must not be empty in frontend-dev mode")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		check(false, "tracing-exporter", "must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+
+	check(
+		c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing-sample-ratio", "must be between 0 and 1",
+	)
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns the config as a flat map keyed like flags, with values
+// tagged secret hidden: secret:"true" entirely, secret:"dsn" only the
+// password, keeping hosts visible for debugging.
+func (c *Config) Redacted() map[string]interface{} {
+	out := make(map[string]interface{})
+	redactInto(out, reflect.ValueOf(*c))
+
+	return out
+}
+
+func redactInto(out map[string]interface{}, v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		key := strings.SplitN(field.Tag.Get("mapstructure"), ",", 2)[0]
+		if field.Type.Kind() == reflect.Struct {
+			redactInto(out, value)
+			continue
+		}
+
+		switch {
+		case value.IsZero():
+			out[key] = value.Interface()
+		case field.Tag.Get("secret") == "true":
+			out[key] = "xxxxx"
+		case field.Tag.Get("secret") == "dsn":
+			out[key] = redactDSN(value.String())
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[key] = value.Interface().(time.Duration).String()
+		default:
+			out[key] = value.Interface()
+		}
+	}
+}
+
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "xxxxx"
+	}
+
+	return u.Redacted()
+}
+
+func isURL(value string, schemes ...string) bool {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isLocale(locale string) bool {
+	return len(locale) == 2 && strings.ToLower(locale) == locale
+}
+
+func isListenAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
 
-	return &config, err
+	return err == nil && port != ""
 }
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/metrics"
+	"00-go-base-tpl-sv/internal/telegram"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	errForbidden          = errors.New("admin role required")
+	errNonceSecretMissing = errors.New("nonce secret is missing")
+)
+
+// TelegramAuth identifies WebApp users by their signed initData and guards
+// state-changing calls with the nonce issued in the bootstrap payload.
+// Users listed in admins have the admin role.
+type TelegramAuth struct {
+	verifier *telegram.Verifier
+	nonces   *nonces
+	metrics  *metrics.Quiz
+	admins   map[int64]bool
+}
+
+func NewTelegramAuth(
+	verifier *telegram.Verifier,
+	nonceSecret string,
+	nonceTTL time.Duration,
+	admins []int64,
+	metrics *metrics.Quiz,
+) (*TelegramAuth, error) {
+	// nonces signed with an empty secret can be forged
+	if nonceSecret == "" {
+		return nil, errNonceSecretMissing
+	}
+
+	adminSet := make(map[int64]bool, len(admins))
+	for _, id := range admins {
+		adminSet[id] = true
+	}
+
+	return &TelegramAuth{
+		verifier: verifier,
+		nonces:   newNonces(nonceSecret, nonceTTL),
+		metrics:  metrics,
+		admins:   adminSet,
+	}, nil
+}
+
+// user verifies initData from the request and, with requireNonce, the
+// X-Nonce header.
+func (a *TelegramAuth) user(r *http.Request, requireNonce bool) (telegram.InitData, error) {
+	data, err := a.verifier.Verify(initDataFromRequest(r))
+	if err != nil {
+		return telegram.InitData{}, err
+	}
+
+	if requireNonce {
+		if err := a.nonces.verify(r, data.User.ID); err != nil {
+			return telegram.InitData{}, err
+		}
+	}
+
+	a.metrics.UserSeen(data.User.ID)
+
+	return data, nil
+}
+
+// admin is user for endpoints restricted to the admin role.
+func (a *TelegramAuth) admin(r *http.Request, requireNonce bool) (telegram.InitData, error) {
+	data, err := a.user(r, requireNonce)
+	if err != nil {
+		return telegram.InitData{}, err
+	}
+
+	if !a.isAdmin(data.User.ID) {
+		return telegram.InitData{}, errForbidden
+	}
+
+	return data, nil
+}
+
+func (a *TelegramAuth) isAdmin(telegramID int64) bool {
+	return a.admins[telegramID]
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"go.uber.org/zap"
+)
+
+const voteDown = "down"
+
+type voteRequest struct {
+	Vote string `json:"vote" validate:"required,oneof=up down"`
+}
+
+type reportRequest struct {
+	Reason string `json:"reason" validate:"max=256"`
+}
+
+// communityQuestionView shows the correct answer, voters judge the whole
+// question.
+type communityQuestionView struct {
+	ID        string            `json:"id"`
+	Question  adminQuestionView `json:"question"`
+	Status    string            `json:"status"`
+	Upvotes   int               `json:"upvotes"`
+	Downvotes int               `json:"downvotes"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type communityQuestionResponse struct {
+	Question communityQuestionView `json:"question"`
+}
+
+type communityQueueResponse struct {
+	Questions []communityQuestionView `json:"questions"`
+}
+
+type Community struct {
+	responder
+
+	service  quiz.CommunityService
+	auth     *TelegramAuth
+	validate *validator.Validate
+}
+
+func NewCommunity(service quiz.CommunityService, auth *TelegramAuth, logger *zap.Logger) *Community {
+	return &Community{
+		responder: responder{logger: logger},
+		service:   service,
+		auth:      auth,
+		validate:  newValidate(),
+	}
+}
+
+func (h *Community) Register(r *mux.Router) {
+	r.HandleFunc("/quiz/community/questions", h.queue).Name("community_queue").Methods("GET")
+	r.HandleFunc("/quiz/community/questions", h.submit).Name("submit_community_question").Methods("POST")
+	r.HandleFunc("/quiz/community/questions/{id}/votes", h.vote).Name("vote_community_question").Methods("POST")
+	r.HandleFunc("/quiz/community/questions/{id}/reports", h.report).Name("report_community_question").Methods("POST")
+}
+
+func (h *Community) queue(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, false)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	questions, err := h.service.Queue(r.Context(), data.User.ID)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	resp := communityQueueResponse{Questions: make([]communityQuestionView, 0, len(questions))}
+	for _, c := range questions {
+		resp.Questions = append(resp.Questions, newCommunityQuestionView(c))
+	}
+
+	h.writeResponse(w, resp)
+}
+
+func (h *Community) submit(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	var req questionRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	c, err := h.service.Submit(r.Context(), data.User.ID, req.question())
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, communityQuestionResponse{Question: newCommunityQuestionView(c)})
+}
+
+func (h *Community) vote(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	var req voteRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	vote := quiz.VoteUp
+	if req.Vote == voteDown {
+		vote = quiz.VoteDown
+	}
+
+	c, err := h.service.Vote(r.Context(), data.User.ID, id, vote)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, communityQuestionResponse{Question: newCommunityQuestionView(c)})
+}
+
+func (h *Community) report(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	var req reportRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	c, err := h.service.Report(r.Context(), data.User.ID, id, req.Reason)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, communityQuestionResponse{Question: newCommunityQuestionView(c)})
+}
+
+func newCommunityQuestionView(c quiz.CommunityQuestion) communityQuestionView {
+	return communityQuestionView{
+		ID:        c.ID.String(),
+		Question:  newAdminQuestionView(c.Question),
+		Status:    string(c.Status),
+		Upvotes:   c.Upvotes,
+		Downvotes: c.Downvotes,
+		CreatedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/player"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+var errPreconditionFailed = errors.New("precondition failed")
+
+type etag struct {
+	value string
+	weak  bool
+}
+
+type etagSet struct {
+	any  bool
+	tags []etag
+}
+
+func parseETagHeader(value string) etagSet {
+	var set etagSet
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if part == "*" {
+			set.any = true
+			continue
+		}
+
+		tag := etag{value: part}
+		if strings.HasPrefix(part, "W/") {
+			tag = etag{value: strings.TrimPrefix(part, "W/"), weak: true}
+		}
+
+		tag.value = strings.Trim(tag.value, `"`)
+		set.tags = append(set.tags, tag)
+	}
+
+	return set
+}
+
+func (s etagSet) empty() bool {
+	return !s.any && len(s.tags) == 0
+}
+
+// contains compares tags to version as RFC 7232 section 2.3.2 says, weak tags
+// never match when strong is set.
+func (s etagSet) contains(version xid.ID, strong bool) bool {
+	if s.any {
+		return true
+	}
+
+	for _, tag := range s.tags {
+		if strong && tag.weak {
+			continue
+		}
+
+		if tag.value == version.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatETag(version xid.ID) string {
+	return `"` + version.String() + `"`
+}
+
+func writeETag(w http.ResponseWriter, version xid.ID) {
+	w.Header().Set("ETag", formatETag(version))
+}
+
+// ifMatchVersion resolves the If-Match header to a single version the service
+// should compare against. Nil version means no precondition. When several tags
+// are given, current is consulted to pick the one matching. If-Match uses the
+// strong comparison, so weak tags never match.
+func ifMatchVersion(r *http.Request, current func() (xid.ID, error)) (xid.ID, error) {
+	set := parseETagHeader(r.Header.Get("If-Match"))
+	if set.empty() || set.any {
+		return xid.NilID(), nil
+	}
+
+	if len(set.tags) == 1 {
+		if set.tags[0].weak {
+			return xid.NilID(), errPreconditionFailed
+		}
+
+		version, err := xid.FromString(set.tags[0].value)
+		if err != nil {
+			return xid.NilID(), errPreconditionFailed
+		}
+
+		return version, nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return xid.NilID(), err
+	}
+
+	if !set.contains(version, true) {
+		return xid.NilID(), errPreconditionFailed
+	}
+
+	return version, nil
+}
+
+func notModified(r *http.Request, version xid.ID) bool {
+	set := parseETagHeader(r.Header.Get("If-None-Match"))
+
+	return !set.empty() && set.contains(version, false)
+}
+
+// preconditionErr reports a version mismatch as a failed precondition when
+// the client sent If-Match, a lost update without one stays a conflict.
+func preconditionErr(r *http.Request, err error) error {
+	if errors.Is(err, player.ErrVersionMismatch) && r.Header.Get("If-Match") != "" {
+		return errPreconditionFailed
+	}
+
+	return err
+}
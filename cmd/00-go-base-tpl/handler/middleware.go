@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/i18n"
+	"00-go-base-tpl-sv/internal/metrics"
+	"00-go-base-tpl-sv/internal/telegram"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+type (
+	requestIDKey struct{}
+	loggerKey    struct{}
+	localizerKey struct{}
+)
+
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = xid.New().String()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// Logging puts a request-scoped logger into the context and logs every
+// finished request. It must be used after RequestID.
+func Logging(log *zap.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+
+			reqLog := log.With(
+				zap.String("request_id", RequestIDFromContext(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("route", routeName(r)),
+			)
+
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				reqLog = reqLog.With(zap.String("trace_id", sc.TraceID().String()))
+			}
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), loggerKey{}, reqLog)))
+
+			fields := []zap.Field{
+				zap.Int("status", sw.status),
+				zap.Duration("latency", time.Since(started)),
+			}
+
+			switch {
+			case sw.status >= http.StatusInternalServerError:
+				reqLog.Error("request finished", fields...)
+			case sw.status >= http.StatusBadRequest:
+				reqLog.Warn("request finished", fields...)
+			default:
+				reqLog.Debug("request finished", fields...)
+			}
+		})
+	}
+}
+
+func LoggerFromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if log, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return log
+	}
+
+	return fallback
+}
+
+// Localization puts the localizer of the user into the context. The language
+// is taken from initData in the Authorization header, unverified as it only
+// picks texts, or else from Accept-Language.
+func Localization(catalog *i18n.Catalog) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := catalog.Localizer(requestLanguage(r))
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), localizerKey{}, l)))
+		})
+	}
+}
+
+// LocalizerFromContext returns the zero Localizer, which has no messages,
+// outside Localization.
+func LocalizerFromContext(ctx context.Context) i18n.Localizer {
+	l, _ := ctx.Value(localizerKey{}).(i18n.Localizer)
+
+	return l
+}
+
+func requestLanguage(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, initDataAuthType) {
+		if code := telegram.LanguageCode(strings.TrimPrefix(auth, initDataAuthType)); code != "" {
+			return code
+		}
+	}
+
+	// the first tag is the preferred one in practice, q-values are ignored
+	lang, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	lang, _, _ = strings.Cut(lang, ";")
+
+	return lang
+}
+
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	return route.GetName()
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func Metrics(m *metrics.HTTP) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				started = time.Now()
+				name    = routeName(r)
+				sw      = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			)
+
+			m.Started(name)
+			next.ServeHTTP(sw, r)
+			m.Finished(name, r.Method, sw.status, time.Since(started))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const nonceHeader = "X-Nonce"
+
+var errInvalidNonce = errors.New("nonce is missing, invalid or expired")
+
+// nonces issues tokens bound to a Telegram user which the page echoes in
+// X-Nonce on state-changing calls, so they can't be forged cross-site.
+// A nonce is issued-at, random bytes and their HMAC with the user ID.
+type nonces struct {
+	key []byte
+	ttl time.Duration
+}
+
+func newNonces(secret string, ttl time.Duration) *nonces {
+	key := sha256.Sum256([]byte("nonce:" + secret))
+
+	return &nonces{key: key[:], ttl: ttl}
+}
+
+func (n *nonces) issue(userID int64) (string, error) {
+	buf := make([]byte, 8+16, 8+16+sha256.Size)
+
+	binary.BigEndian.PutUint64(buf, uint64(time.Now().Unix()))
+
+	if _, err := rand.Read(buf[8:]); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append(buf, n.sign(buf, userID)...)), nil
+}
+
+func (n *nonces) verify(r *http.Request, userID int64) error {
+	data, err := base64.RawURLEncoding.DecodeString(r.Header.Get(nonceHeader))
+	if err != nil || len(data) != 8+16+sha256.Size {
+		return errInvalidNonce
+	}
+
+	payload, mac := data[:8+16], data[8+16:]
+	if !hmac.Equal(mac, n.sign(payload, userID)) {
+		return errInvalidNonce
+	}
+
+	issuedAt := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	if n.ttl > 0 && time.Since(issuedAt) > n.ttl {
+		return errInvalidNonce
+	}
+
+	return nil
+}
+
+func (n *nonces) sign(payload []byte, userID int64) []byte {
+	mac := hmac.New(sha256.New, n.key)
+	mac.Write(payload) // nolint hash writes never fail
+	_ = binary.Write(mac, binary.BigEndian, userID)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/player"
+	"errors"
+	"mime"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	errUnsupportedContentType = errors.New("content type must be application/merge-patch+json or application/json")
+	errMergePatchNotObject    = errors.New("merge patch must be a JSON object")
+)
+
+func isMergePatchContentType(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
+
+// playerPatchFromMergePatch converts an RFC 7396 document into a player patch.
+// Player fields are required, so removing them with null is rejected.
+func playerPatchFromMergePatch(v *validator.Validate, doc map[string]interface{}) (player.Patch, error) {
+	var (
+		patch  player.Patch
+		req    playerRequest
+		fields []string
+		errs   []fieldError
+	)
+
+	if doc == nil {
+		return patch, errMergePatchNotObject
+	}
+
+	for field, value := range doc {
+		switch field {
+		case "email":
+			patch.Email, errs = patchString(field, value, errs)
+			if patch.Email != nil {
+				req.Email = *patch.Email
+				fields = append(fields, "Email")
+			}
+		case "name":
+			patch.Name, errs = patchString(field, value, errs)
+			if patch.Name != nil {
+				req.Name = *patch.Name
+				fields = append(fields, "Name")
+			}
+		default:
+			errs = append(errs, fieldError{Field: field, Message: "is not a known field"})
+		}
+	}
+
+	if len(fields) > 0 {
+		var vErr *validationError
+		if err := validateStruct(v, req, fields...); errors.As(err, &vErr) {
+			errs = append(errs, vErr.Fields...)
+		} else if err != nil {
+			return patch, err
+		}
+	}
+
+	if len(errs) > 0 {
+		return patch, &validationError{Fields: errs}
+	}
+
+	return patch, nil
+}
+
+func patchString(field string, value interface{}, errs []fieldError) (*string, []fieldError) {
+	if value == nil {
+		return nil, append(errs, fieldError{Field: field, Message: "cannot be removed"})
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, append(errs, fieldError{Field: field, Message: "must be a string"})
+	}
+
+	return &str, errs
+}
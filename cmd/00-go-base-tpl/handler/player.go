@@ -2,26 +2,21 @@ package handler
 
 import (
 	"00-go-base-tpl-sv/internal/player"
-	"errors"
+	"context"
 	"fmt"
 	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"github.com/mymmrac/telego"
 	"github.com/rs/xid"
 	"go.uber.org/zap"
-	"html/template"
 	"net/http"
-	"os"
 )
 
 type playerRequest struct {
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required"`
-}
-
-type errorResponse struct {
-	Error error `json:"error"`
+	Name  string `json:"name" validate:"required,notblank,max=64"`
+	Email string `json:"email" validate:"required,email,max=254"`
 }
 
 type playerResponse struct {
@@ -33,85 +28,42 @@ type playersResponse struct {
 }
 
 type Players struct {
-	service player.Service
-	logger  *zap.Logger
-}
-
-type Question struct {
-	Text               string `json:"text" validate:"required"`
-	Option1            string `json:"option_1" validate:"required"`
-	Option2            string `json:"option_2" validate:"required"`
-	Option3            string `json:"option_3" validate:"required"`
-	Option4            string `json:"option_4" validate:"required"`
-	CorrectOptionValue string `json:"correct_option" validate:"required"`
+	responder
+
+	service  player.Service
+	importer *PlayerImporter
+	validate *validator.Validate
+	logger   *zap.Logger
+	// botToken is the token WebApp init data is verified with, /me and
+	// /callback talk to the Bot API as the same bot.
+	botToken string
 }
 
-func NewPlayers(service player.Service, logger *zap.Logger) *Players {
-	return &Players{service: service, logger: logger}
+func NewPlayers(service player.Service, importer *PlayerImporter, botToken string, logger *zap.Logger) *Players {
+	return &Players{
+		responder: responder{logger: logger},
+		service:   service,
+		importer:  importer,
+		validate:  newValidate(),
+		logger:    logger,
+		botToken:  botToken,
+	}
 }
 
 func (h *Players) Register(r *mux.Router) {
-	r.HandleFunc("/", h.app).Name("home").Methods("GET")
-	r.HandleFunc("/questions", h.getQuestions).Name("questions").Methods("GET")
-
 	r.HandleFunc("/me", h.me).Name("me").Methods("GET")
 	r.HandleFunc("/callback", h.handleCallback).Name("callback").Methods("GET")
 
 	r.HandleFunc("/players", h.list).Name("list_players").Methods("GET")
 	r.HandleFunc("/players", h.create).Name("create_player").Methods("POST")
+	r.HandleFunc("/players:batch", h.batch).Name("batch_create_players").Methods("POST")
 	r.HandleFunc("/players/filter", h.filter).Name("filter_players").Methods("GET")
 	r.HandleFunc("/players/{id}", h.read).Name("read_player").Methods("GET")
-	r.HandleFunc("/players/{id}", h.update).Name("update_player").Methods("PATCH", "PUT")
+	r.HandleFunc("/players/{id}", h.update).Name("update_player").Methods("PUT")
+	r.HandleFunc("/players/{id}", h.patch).Name("patch_player").Methods("PATCH")
 	r.HandleFunc("/players/{id}", h.delete).Name("delete_player").Methods("DELETE")
 }
 
-func (h *Players) writeServiceErr(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, player.ErrNotFound):
-		h.writeErr(
-			w,
-			err,
-			http.StatusNotFound,
-		)
-	case errors.Is(err, player.ErrConflict):
-		h.writeErr(
-			w,
-			err,
-			http.StatusConflict,
-		)
-	case errors.Is(err, player.ErrVersionMismatch):
-		h.writeErr(
-			w,
-			err,
-			http.StatusConflict,
-		)
-	default:
-		h.writeErr(
-			w,
-			err,
-			http.StatusInternalServerError,
-		)
-	}
-}
-
-func (h *Players) writeErr(w http.ResponseWriter, err error, status int) {
-	w.WriteHeader(status)
-	h.writeResponse(w, errorResponse{Error: err})
-}
-
-func (h *Players) writeResponse(w http.ResponseWriter, v interface{}) {
-	data, err := sonic.ConfigFastest.Marshal(v)
-	if err != nil {
-		h.logger.Error("json marshal error", zap.Error(err))
-		return
-	}
-
-	_, err = w.Write(data)
-	if err != nil {
-		h.logger.Error("write error", zap.Error(err))
-	}
-}
-
 func (h *Players) create(w http.ResponseWriter, r *http.Request) {
 	var req playerRequest
 
@@ -119,7 +71,12 @@ func (h *Players) create(w http.ResponseWriter, r *http.Request) {
 	err := dec.Decode(&req)
 
 	if err != nil {
-		h.writeErr(w, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
@@ -127,23 +84,31 @@ func (h *Players) create(w http.ResponseWriter, r *http.Request) {
 
 	p, err := h.service.Create(ctx, req.Email, req.Name)
 	if err != nil {
-		h.writeServiceErr(w, err)
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
+	writeETag(w, p.Version)
 	h.writeResponse(w, playerResponse{Player: p})
 }
 
 func (h *Players) read(w http.ResponseWriter, r *http.Request) {
 	id, err := xid.FromString(mux.Vars(r)["id"])
 	if err != nil {
-		h.writeErr(w, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
 		return
 	}
 
 	p, err := h.service.Read(r.Context(), id)
 	if err != nil {
-		h.writeServiceErr(w, err)
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	writeETag(w, p.Version)
+
+	if notModified(r, p.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -156,89 +121,132 @@ func (h *Players) update(w http.ResponseWriter, r *http.Request) {
 
 	err := dec.Decode(&req)
 	if err != nil {
-		h.writeErr(w, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
 	id, err := xid.FromString(mux.Vars(r)["id"])
 	if err != nil {
-		h.writeErr(w, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
 		return
 	}
 
 	ctx := r.Context()
 
-	p, err := h.service.Update(ctx, id, req.Email, req.Name)
+	version, err := ifMatchVersion(r, h.currentVersion(ctx, id))
 	if err != nil {
-		h.writeServiceErr(w, err)
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
+	p, err := h.service.Update(ctx, id, version, req.Email, req.Name)
+	if err != nil {
+		h.writeServiceErr(w, r, preconditionErr(r, err))
+		return
+	}
+
+	writeETag(w, p.Version)
 	h.writeResponse(w, playerResponse{Player: p})
 }
 
-func (h *Players) delete(w http.ResponseWriter, r *http.Request) {
-	id, err := xid.FromString(mux.Vars(r)["id"])
+func (h *Players) patch(w http.ResponseWriter, r *http.Request) {
+	if !isMergePatchContentType(r.Header.Get("Content-Type")) {
+		h.writeServiceErr(w, r, errUnsupportedContentType)
+		return
+	}
+
+	var doc map[string]interface{}
+
+	err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&doc)
 	if err != nil {
-		h.writeErr(w, err, http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.Delete(r.Context(), id); err != nil {
-		h.writeServiceErr(w, err)
+	patch, err := playerPatchFromMergePatch(h.validate, doc)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
-	h.writeResponse(w, struct{}{})
-}
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
 
-func (h *Players) home(w http.ResponseWriter, r *http.Request) {
-	h.writeResponse(w, "ok")
-}
+	ctx := r.Context()
 
-func (h *Players) getQuestions(w http.ResponseWriter, r *http.Request) {
-	q1 := Question{"What hero has finger?", "Lina", "Lion", "Templar Assasin", "Spirit braker", "Lion"}
-	q2 := Question{"What can dive?", "Pudge", "Io", "Ember spirit", "Phoenix", "Phoenix"}
+	version, err := ifMatchVersion(r, h.currentVersion(ctx, id))
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
 
-	questions := []Question{q1, q2}
+	p, err := h.service.Patch(ctx, id, version, patch)
+	if err != nil {
+		h.writeServiceErr(w, r, preconditionErr(r, err))
+		return
+	}
 
-	h.writeResponse(w, questions)
+	writeETag(w, p.Version)
+	h.writeResponse(w, playerResponse{Player: p})
 }
 
-func (h *Players) app(w http.ResponseWriter, r *http.Request) {
-	// Parse the HTML file
-	tmpl, err := template.ParseFiles("../../internal/frontend/index.html")
+func (h *Players) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := xid.FromString(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
 		return
 	}
 
-	// Data to pass into the template
-	data := struct {
-		Name string
-	}{
-		Name: "Variable!@#123123",
-	}
+	ctx := r.Context()
 
-	// Render the template
-	err = tmpl.Execute(w, data)
+	version, err := ifMatchVersion(r, h.currentVersion(ctx, id))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeServiceErr(w, r, err)
 		return
 	}
+
+	if err := h.service.Delete(ctx, id, version); err != nil {
+		h.writeServiceErr(w, r, preconditionErr(r, err))
+		return
+	}
+
+	h.writeResponse(w, struct{}{})
 }
 
-func (h *Players) handleCallback(w http.ResponseWriter, r *http.Request) {
-	botToken := "6484612269:AAGMCqUOTmfDI4KDPfumW3hZO3s0LmcoozQ" // todo
+func (h *Players) currentVersion(ctx context.Context, id xid.ID) func() (xid.ID, error) {
+	return func() (xid.ID, error) {
+		p, err := h.service.Read(ctx, id)
+		if err != nil {
+			return xid.NilID(), err
+		}
+
+		return p.Version, nil
+	}
+}
+
+func (h *Players) home(w http.ResponseWriter, r *http.Request) {
+	h.writeResponse(w, "ok")
+}
 
+func (h *Players) handleCallback(w http.ResponseWriter, r *http.Request) {
 	// Note: Please keep in mind that default logger may expose sensitive information,
 	// use in development only
-	bot, err := telego.NewBot(botToken, telego.WithDefaultDebugLogger())
+	bot, err := telego.NewBot(h.botToken, telego.WithDefaultDebugLogger())
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		h.writeServiceErr(w, r, fmt.Errorf("create bot: %w", err))
+		return
 	}
 
+	log := LoggerFromContext(r.Context(), h.logger)
+
 	// Get updates channel
 	// (more on configuration in examples/updates_long_polling/main.go)
 	updates, _ := bot.UpdatesViaLongPolling(nil)
@@ -248,33 +256,30 @@ func (h *Players) handleCallback(w http.ResponseWriter, r *http.Request) {
 
 	// Loop through all updates when they came
 	for update := range updates {
-		fmt.Printf("Update: %+v\n", update)
+		log.Debug("bot update", zap.Int("update_id", update.UpdateID))
 	}
 
 	h.writeResponse(w, updates)
 }
 func (h *Players) me(w http.ResponseWriter, r *http.Request) {
-	// Get Bot token from environment variables
-	botToken := "6484612269:AAGMCqUOTmfDI4KDPfumW3hZO3s0LmcoozQ" // todo
-
 	// Create bot and enable debugging info
 	// Note: Please keep in mind that default logger may expose sensitive information,
 	// use in development only
 	// (more on configuration in examples/configuration/main.go)
-	bot, err := telego.NewBot(botToken, telego.WithDefaultDebugLogger())
+	bot, err := telego.NewBot(h.botToken, telego.WithDefaultDebugLogger())
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		h.writeServiceErr(w, r, fmt.Errorf("create bot: %w", err))
+		return
 	}
 
 	// Call method getMe (https://core.telegram.org/bots/api#getme)
 	botUser, err := bot.GetMe()
 	if err != nil {
-		fmt.Println("Error:", err)
+		h.writeServiceErr(w, r, fmt.Errorf("get bot user: %w", err))
+		return
 	}
 
-	// Print Bot information
-	fmt.Printf("Bot user: %+v\n", botUser)
+	LoggerFromContext(r.Context(), h.logger).Debug("bot user", zap.String("username", botUser.Username))
 
 	h.writeResponse(w, botUser)
 }
@@ -282,7 +287,7 @@ func (h *Players) me(w http.ResponseWriter, r *http.Request) {
 func (h *Players) list(w http.ResponseWriter, r *http.Request) {
 	pp, err := h.service.List(r.Context())
 	if err != nil {
-		h.writeServiceErr(w, err)
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
@@ -290,10 +295,10 @@ func (h *Players) list(w http.ResponseWriter, r *http.Request) {
 }
 
 type filterReq struct {
-	Name   string `schema:"name"`
-	Email  string `schema:"email"`
+	Name   string `schema:"name" validate:"max=64"`
+	Email  string `schema:"email" validate:"max=254"`
 	Offset uint   `schema:"offset"`
-	Limit  uint   `schema:"limit"`
+	Limit  uint   `schema:"limit" validate:"max=100"`
 }
 
 type filterResponse struct {
@@ -306,7 +311,12 @@ func (h *Players) filter(w http.ResponseWriter, r *http.Request) {
 
 	err := schema.NewDecoder().Decode(&req, r.URL.Query())
 	if err != nil {
-		h.writeErr(w, fmt.Errorf("decode query: %w", err), http.StatusBadRequest)
+		h.writeErr(w, r, fmt.Errorf("decode query: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
@@ -320,7 +330,7 @@ func (h *Players) filter(w http.ResponseWriter, r *http.Request) {
 		req.Limit,
 	)
 	if err != nil {
-		h.writeServiceErr(w, err)
+		h.writeServiceErr(w, r, err)
 		return
 	}
 
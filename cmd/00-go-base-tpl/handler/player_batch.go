@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/player"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	maxImportRows      = 10000
+	maxImportBodyBytes = 32 << 20
+)
+
+type ImportFormat string
+
+const (
+	ImportFormatNDJSON ImportFormat = "ndjson"
+	ImportFormatCSV    ImportFormat = "csv"
+)
+
+const (
+	importStatusCreated  = "created"
+	importStatusConflict = "conflict"
+	importStatusInvalid  = "invalid"
+	importStatusSkipped  = "skipped"
+	importStatusFailed   = "failed"
+)
+
+var (
+	errUnknownImportFormat   = errors.New("unknown import format, expected ndjson or csv")
+	errUnsupportedImportType = errors.New("content type must be application/x-ndjson or text/csv")
+	errInvalidImport         = errors.New("invalid import payload")
+	errTooManyImportRows     = fmt.Errorf("import is limited to %d rows", maxImportRows)
+)
+
+type ImportRow struct {
+	Row    int            `json:"row"`
+	Status string         `json:"status"`
+	Player *player.Player `json:"player,omitempty"`
+	Fields []fieldError   `json:"fields,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created  int         `json:"created"`
+	Conflict int         `json:"conflict"`
+	Invalid  int         `json:"invalid"`
+	Skipped  int         `json:"skipped"`
+	Failed   int         `json:"failed"`
+	Rows     []ImportRow `json:"rows"`
+}
+
+type PlayerImporter struct {
+	service  player.Service
+	validate *validator.Validate
+}
+
+func NewPlayerImporter(service player.Service) *PlayerImporter {
+	return &PlayerImporter{service: service, validate: newValidate()}
+}
+
+func ParseImportFormat(value string) (ImportFormat, error) {
+	switch strings.ToLower(value) {
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl":
+		return ImportFormatNDJSON, nil
+	case "csv", "text/csv":
+		return ImportFormatCSV, nil
+	default:
+		return "", errUnknownImportFormat
+	}
+}
+
+// Import reads players in the given format and inserts every valid row.
+// Invalid rows and storage conflicts are reported per row and never abort the
+// rest of the batch, unless ordered mode is requested for storage failures.
+func (i *PlayerImporter) Import(ctx context.Context, r io.Reader, format ImportFormat, ordered bool) (ImportReport, error) {
+	var (
+		reqs []playerRequest
+		err  error
+	)
+
+	switch format {
+	case ImportFormatNDJSON:
+		reqs, err = readNDJSONPlayers(r)
+	case ImportFormatCSV:
+		reqs, err = readCSVPlayers(r)
+	default:
+		err = errUnknownImportFormat
+	}
+
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("%w: %w", errInvalidImport, err)
+	}
+
+	report := ImportReport{Rows: make([]ImportRow, len(reqs))}
+
+	var (
+		valid    = make([]player.NewPlayer, 0, len(reqs))
+		validIdx = make([]int, 0, len(reqs))
+	)
+
+	for idx, req := range reqs {
+		report.Rows[idx].Row = idx + 1
+
+		if err := validateStruct(i.validate, req); err != nil {
+			var vErr *validationError
+			if errors.As(err, &vErr) {
+				report.Rows[idx].Fields = vErr.Fields
+			} else {
+				report.Rows[idx].Error = err.Error()
+			}
+
+			report.Rows[idx].Status = importStatusInvalid
+			report.Invalid++
+
+			continue
+		}
+
+		valid = append(valid, player.NewPlayer{Email: req.Email, Name: req.Name})
+		validIdx = append(validIdx, idx)
+	}
+
+	results, err := i.service.CreateMany(ctx, valid, ordered)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	for n, res := range results {
+		row := &report.Rows[validIdx[n]]
+
+		switch {
+		case res.Err == nil:
+			p := res.Player
+			row.Player = &p
+			row.Status = importStatusCreated
+			report.Created++
+		case errors.Is(res.Err, player.ErrConflict):
+			row.Status = importStatusConflict
+			row.Error = res.Err.Error()
+			report.Conflict++
+		case errors.Is(res.Err, player.ErrSkipped):
+			row.Status = importStatusSkipped
+			row.Error = res.Err.Error()
+			report.Skipped++
+		default:
+			row.Status = importStatusFailed
+			row.Error = res.Err.Error()
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func readNDJSONPlayers(r io.Reader) ([]playerRequest, error) {
+	var (
+		reqs    []playerRequest
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for scanner.Scan() {
+		line++
+
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		if len(reqs) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		var req playerRequest
+		if err := sonic.ConfigFastest.UnmarshalFromString(data, &req); err != nil {
+			return nil, fmt.Errorf("line %d: unmarshal player: %w", line, err)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ndjson: %w", err)
+	}
+
+	return reqs, nil
+}
+
+func readCSVPlayers(r io.Reader) ([]playerRequest, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := map[string]int{"email": -1, "name": -1}
+	for idx, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; ok {
+			columns[name] = idx
+		}
+	}
+
+	for name, idx := range columns {
+		if idx < 0 {
+			return nil, fmt.Errorf("csv header: missing %q column", name)
+		}
+	}
+
+	cr.FieldsPerRecord = len(header)
+
+	var reqs []playerRequest
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+
+		if len(reqs) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		reqs = append(reqs, playerRequest{
+			Email: strings.TrimSpace(record[columns["email"]]),
+			Name:  strings.TrimSpace(record[columns["name"]]),
+		})
+	}
+
+	return reqs, nil
+}
+
+func (h *Players) batch(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.writeServiceErr(w, r, errUnsupportedImportType)
+		return
+	}
+
+	format, err := ParseImportFormat(mediaType)
+	if err != nil {
+		h.writeServiceErr(w, r, errUnsupportedImportType)
+		return
+	}
+
+	var ordered bool
+
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "unordered":
+	case "ordered":
+		ordered = true
+	default:
+		h.writeErr(w, r, fmt.Errorf("unknown mode %q, expected ordered or unordered", mode), http.StatusBadRequest)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+
+	report, err := h.importer.Import(r.Context(), body, format, ordered)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, report)
+}
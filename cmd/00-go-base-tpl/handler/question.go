@@ -0,0 +1,480 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"go.uber.org/zap"
+)
+
+type optionRequest struct {
+	ID      string `json:"id" yaml:"id" validate:"required,max=64"`
+	Text    string `json:"text" yaml:"text" validate:"required,max=256"`
+	Correct bool   `json:"correct" yaml:"correct"`
+}
+
+type mediaRequest struct {
+	Kind string `json:"kind" yaml:"kind" validate:"required"`
+	URL  string `json:"url" yaml:"url" validate:"required,max=2048"`
+	Alt  string `json:"alt,omitempty" yaml:"alt,omitempty" validate:"max=256"`
+}
+
+type numericRequest struct {
+	Value     float64 `json:"value" yaml:"value"`
+	Tolerance float64 `json:"tolerance" yaml:"tolerance" validate:"min=0"`
+	Unit      string  `json:"unit,omitempty" yaml:"unit,omitempty" validate:"max=16"`
+}
+
+// questionRequest is checked for size here, the rules of question types
+// are checked by quiz.Question.Validate. Imports and exports use the same
+// shape, hence the yaml tags.
+type questionRequest struct {
+	ExternalID string          `json:"external_id,omitempty" yaml:"external_id,omitempty" validate:"max=128"`
+	Type       string          `json:"type" yaml:"type" validate:"required"`
+	Text       string          `json:"text" yaml:"text" validate:"required,max=1024"`
+	Media      *mediaRequest   `json:"media,omitempty" yaml:"media,omitempty"`
+	Options    []optionRequest `json:"options,omitempty" yaml:"options,omitempty" validate:"max=10,dive"`
+	Numeric    *numericRequest `json:"numeric,omitempty" yaml:"numeric,omitempty"`
+	Category   string          `json:"category" yaml:"category" validate:"required,max=64"`
+	Tags       []string        `json:"tags" yaml:"tags" validate:"max=10,dive,required,max=32"`
+	Difficulty int             `json:"difficulty" yaml:"difficulty" validate:"required"`
+	Language   string          `json:"language" yaml:"language" validate:"required"`
+}
+
+type rejectRequest struct {
+	Note string `json:"note" validate:"required,max=1024"`
+}
+
+type adminOptionView struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+}
+
+type numericView struct {
+	Value     float64 `json:"value"`
+	Tolerance float64 `json:"tolerance"`
+	Unit      string  `json:"unit,omitempty"`
+}
+
+// adminQuestionView, unlike questionView, shows the correct answer.
+type adminQuestionView struct {
+	ID         string            `json:"id"`
+	ExternalID string            `json:"external_id,omitempty"`
+	Type       string            `json:"type"`
+	Text       string            `json:"text"`
+	Media      *mediaView        `json:"media,omitempty"`
+	Options    []adminOptionView `json:"options,omitempty"`
+	Numeric    *numericView      `json:"numeric,omitempty"`
+	Category   string            `json:"category"`
+	Tags       []string          `json:"tags"`
+	Difficulty int               `json:"difficulty"`
+	Language   string            `json:"language"`
+	Revision   int               `json:"revision"`
+	Status     string            `json:"status,omitempty"`
+}
+
+type revisionView struct {
+	ID          string            `json:"id"`
+	QuestionID  string            `json:"question_id"`
+	Number      int               `json:"number"`
+	Status      string            `json:"status"`
+	Question    adminQuestionView `json:"question"`
+	AuthorID    int64             `json:"author_id"`
+	ReviewerID  int64             `json:"reviewer_id,omitempty"`
+	ReviewNote  string            `json:"review_note,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	SubmittedAt *time.Time        `json:"submitted_at,omitempty"`
+	ReviewedAt  *time.Time        `json:"reviewed_at,omitempty"`
+}
+
+type revisionResponse struct {
+	Revision revisionView `json:"revision"`
+}
+
+type revisionsResponse struct {
+	Revisions []revisionView `json:"revisions"`
+}
+
+type adminQuestionResponse struct {
+	Question adminQuestionView `json:"question"`
+}
+
+// Questions is the authoring API, every endpoint requires the admin role.
+type Questions struct {
+	responder
+
+	service  quiz.AuthoringService
+	importer *QuestionImporter
+	auth     *TelegramAuth
+	validate *validator.Validate
+}
+
+func NewQuestions(
+	service quiz.AuthoringService,
+	importer *QuestionImporter,
+	auth *TelegramAuth,
+	logger *zap.Logger,
+) *Questions {
+	return &Questions{
+		responder: responder{logger: logger},
+		service:   service,
+		importer:  importer,
+		auth:      auth,
+		validate:  newValidate(),
+	}
+}
+
+func (h *Questions) Register(r *mux.Router) {
+	r.HandleFunc("/admin/questions", h.list).Name("list_question_revisions").Methods("GET")
+	r.HandleFunc("/admin/questions", h.create).Name("create_question").Methods("POST")
+	r.HandleFunc("/admin/questions/import", h.importQuestions).Name("import_questions").Methods("POST")
+	r.HandleFunc("/admin/questions/export", h.exportQuestions).Name("export_questions").Methods("GET")
+	r.HandleFunc("/admin/questions/{id}", h.edit).Name("edit_question").Methods("PUT")
+	r.HandleFunc("/admin/questions/{id}/revisions", h.revisions).Name("question_revisions").Methods("GET")
+	r.HandleFunc("/admin/questions/{id}/submit", h.submit).Name("submit_question").Methods("POST")
+	r.HandleFunc("/admin/questions/{id}/approve", h.approve).Name("approve_question").Methods("POST")
+	r.HandleFunc("/admin/questions/{id}/reject", h.reject).Name("reject_question").Methods("POST")
+	r.HandleFunc("/admin/questions/{id}/retire", h.retire).Name("retire_question").Methods("POST")
+}
+
+// list returns revisions in the "status" query parameter, the review queue
+// by default.
+func (h *Questions) list(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, false); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	status := quiz.RevisionStatus(r.URL.Query().Get("status"))
+
+	switch status {
+	case "":
+		status = quiz.RevisionInReview
+	case quiz.RevisionDraft, quiz.RevisionInReview, quiz.RevisionApproved, quiz.RevisionRejected:
+	default:
+		h.writeErr(w, r, fmt.Errorf("unknown status %q", status), http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.ListByStatus(r.Context(), status)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, newRevisionsResponse(revisions))
+}
+
+func (h *Questions) create(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	q, ok := h.decodeQuestion(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := h.service.Create(r.Context(), data.User.ID, q)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, revisionResponse{Revision: newRevisionView(rev)})
+}
+
+func (h *Questions) edit(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, ok := h.questionID(w, r)
+	if !ok {
+		return
+	}
+
+	q, ok := h.decodeQuestion(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := h.service.Edit(r.Context(), data.User.ID, id, q)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, revisionResponse{Revision: newRevisionView(rev)})
+}
+
+func (h *Questions) revisions(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, false); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, ok := h.questionID(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.Revisions(r.Context(), id)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, newRevisionsResponse(revisions))
+}
+
+func (h *Questions) submit(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Submit)
+}
+
+func (h *Questions) approve(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Approve)
+}
+
+func (h *Questions) reject(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, ok := h.questionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req rejectRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	rev, err := h.service.Reject(r.Context(), data.User.ID, id, req.Note)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, revisionResponse{Revision: newRevisionView(rev)})
+}
+
+func (h *Questions) retire(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, true); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, ok := h.questionID(w, r)
+	if !ok {
+		return
+	}
+
+	q, err := h.service.Retire(r.Context(), id)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, adminQuestionResponse{Question: newAdminQuestionView(q)})
+}
+
+// transition handles body-less workflow steps acting as the current admin.
+func (h *Questions) transition(
+	w http.ResponseWriter,
+	r *http.Request,
+	step func(ctx context.Context, telegramID int64, questionID xid.ID) (quiz.Revision, error),
+) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, ok := h.questionID(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := step(r.Context(), data.User.ID, id)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, revisionResponse{Revision: newRevisionView(rev)})
+}
+
+func (h *Questions) questionID(w http.ResponseWriter, r *http.Request) (xid.ID, bool) {
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return xid.ID{}, false
+	}
+
+	return id, true
+}
+
+func (h *Questions) decodeQuestion(w http.ResponseWriter, r *http.Request) (quiz.Question, bool) {
+	var req questionRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return quiz.Question{}, false
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return quiz.Question{}, false
+	}
+
+	return req.question(), true
+}
+
+func (req questionRequest) question() quiz.Question {
+	q := quiz.Question{
+		ExternalID: req.ExternalID,
+		Type:       quiz.QuestionType(req.Type),
+		Text:       req.Text,
+		Category:   req.Category,
+		Tags:       req.Tags,
+		Difficulty: req.Difficulty,
+		Language:   req.Language,
+	}
+
+	if q.Tags == nil {
+		q.Tags = []string{}
+	}
+
+	if req.Media != nil {
+		q.Media = &quiz.Media{Kind: quiz.MediaKind(req.Media.Kind), URL: req.Media.URL, Alt: req.Media.Alt}
+	}
+
+	for _, o := range req.Options {
+		q.Options = append(q.Options, quiz.Option{ID: o.ID, Text: o.Text, Correct: o.Correct})
+	}
+
+	if req.Numeric != nil {
+		q.Numeric = &quiz.NumericAnswer{
+			Value:     req.Numeric.Value,
+			Tolerance: req.Numeric.Tolerance,
+			Unit:      req.Numeric.Unit,
+		}
+	}
+
+	return q
+}
+
+// newQuestionRequest is the inverse of questionRequest.question, used to
+// export questions in the shape they are imported.
+func newQuestionRequest(q quiz.Question) questionRequest {
+	req := questionRequest{
+		ExternalID: q.ExternalID,
+		Type:       string(q.Type),
+		Text:       q.Text,
+		Category:   q.Category,
+		Tags:       q.Tags,
+		Difficulty: q.Difficulty,
+		Language:   q.Language,
+	}
+
+	if req.ExternalID == "" {
+		req.ExternalID = q.ID.String()
+	}
+
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+
+	if q.Media != nil {
+		req.Media = &mediaRequest{Kind: string(q.Media.Kind), URL: q.Media.URL, Alt: q.Media.Alt}
+	}
+
+	for _, o := range q.Options {
+		req.Options = append(req.Options, optionRequest{ID: o.ID, Text: o.Text, Correct: o.Correct})
+	}
+
+	if q.Numeric != nil {
+		req.Numeric = &numericRequest{Value: q.Numeric.Value, Tolerance: q.Numeric.Tolerance, Unit: q.Numeric.Unit}
+	}
+
+	return req
+}
+
+func newAdminQuestionView(q quiz.Question) adminQuestionView {
+	v := adminQuestionView{
+		ID:         q.ID.String(),
+		ExternalID: q.ExternalID,
+		Type:       string(q.Type),
+		Text:       q.Text,
+		Category:   q.Category,
+		Tags:       q.Tags,
+		Difficulty: q.Difficulty,
+		Language:   q.Language,
+		Revision:   q.Revision,
+		Status:     string(q.Status),
+	}
+
+	if q.Media != nil {
+		v.Media = &mediaView{Kind: string(q.Media.Kind), URL: q.Media.URL, Alt: q.Media.Alt}
+	}
+
+	for _, o := range q.Options {
+		v.Options = append(v.Options, adminOptionView{ID: o.ID, Text: o.Text, Correct: o.Correct})
+	}
+
+	if q.Numeric != nil {
+		v.Numeric = &numericView{Value: q.Numeric.Value, Tolerance: q.Numeric.Tolerance, Unit: q.Numeric.Unit}
+	}
+
+	return v
+}
+
+func newRevisionView(r quiz.Revision) revisionView {
+	return revisionView{
+		ID:          r.ID.String(),
+		QuestionID:  r.QuestionID.String(),
+		Number:      r.Number,
+		Status:      string(r.Status),
+		Question:    newAdminQuestionView(r.Question),
+		AuthorID:    r.AuthorID,
+		ReviewerID:  r.ReviewerID,
+		ReviewNote:  r.ReviewNote,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		SubmittedAt: r.SubmittedAt,
+		ReviewedAt:  r.ReviewedAt,
+	}
+}
+
+func newRevisionsResponse(revisions []quiz.Revision) revisionsResponse {
+	resp := revisionsResponse{Revisions: make([]revisionView, 0, len(revisions))}
+	for _, r := range revisions {
+		resp.Revisions = append(resp.Revisions, newRevisionView(r))
+	}
+
+	return resp
+}
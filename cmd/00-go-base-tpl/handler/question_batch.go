@@ -0,0 +1,673 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/xid"
+	"gopkg.in/yaml.v3"
+)
+
+type QuestionFormat string
+
+const (
+	QuestionFormatJSON QuestionFormat = "json"
+	QuestionFormatCSV  QuestionFormat = "csv"
+	QuestionFormatYAML QuestionFormat = "yaml"
+)
+
+const importStatusUpdated = "updated"
+
+// csvListSeparator splits tags and correct option numbers within a cell.
+const csvListSeparator = "|"
+
+var (
+	errUnknownQuestionFormat   = errors.New("unknown question format, expected json, csv or yaml")
+	errUnsupportedQuestionType = errors.New("content type must be application/json, text/csv or application/yaml")
+	errDuplicateExternalID     = errors.New("external_id is repeated in the import")
+)
+
+type QuestionImportRow struct {
+	Row        int          `json:"row"`
+	ExternalID string       `json:"external_id,omitempty"`
+	Status     string       `json:"status"`
+	QuestionID string       `json:"question_id,omitempty"`
+	Revision   int          `json:"revision,omitempty"`
+	Fields     []fieldError `json:"fields,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// QuestionImportReport lists what happened to every row. In a dry run the
+// statuses say what would happen, nothing is written.
+type QuestionImportReport struct {
+	DryRun   bool                `json:"dry_run"`
+	Created  int                 `json:"created"`
+	Updated  int                 `json:"updated"`
+	Invalid  int                 `json:"invalid"`
+	Conflict int                 `json:"conflict"`
+	Failed   int                 `json:"failed"`
+	Rows     []QuestionImportRow `json:"rows"`
+}
+
+// questionRecord is a parsed row, err is set when a CSV cell couldn't be
+// read into the question.
+type questionRecord struct {
+	req questionRequest
+	err error
+}
+
+// QuestionImporter reads and writes questions in the formats content teams
+// keep them in. Imported questions are submitted for review, upserting by
+// external ID.
+type QuestionImporter struct {
+	service  quiz.AuthoringService
+	validate *validator.Validate
+}
+
+func NewQuestionImporter(service quiz.AuthoringService) *QuestionImporter {
+	return &QuestionImporter{service: service, validate: newValidate()}
+}
+
+func ParseQuestionFormat(value string) (QuestionFormat, error) {
+	switch strings.ToLower(value) {
+	case "json", "application/json":
+		return QuestionFormatJSON, nil
+	case "csv", "text/csv":
+		return QuestionFormatCSV, nil
+	case "yaml", "yml", "application/yaml", "application/x-yaml", "text/yaml":
+		return QuestionFormatYAML, nil
+	default:
+		return "", errUnknownQuestionFormat
+	}
+}
+
+func (f QuestionFormat) ContentType() string {
+	switch f {
+	case QuestionFormatCSV:
+		return "text/csv; charset=utf-8"
+	case QuestionFormatYAML:
+		return "application/yaml"
+	default:
+		return "application/json"
+	}
+}
+
+// Import reports invalid rows without stopping at them. Valid rows create a
+// question, or a new revision of the question with the same external ID,
+// and submit it for review.
+func (i *QuestionImporter) Import(
+	ctx context.Context,
+	r io.Reader,
+	format QuestionFormat,
+	authorID int64,
+	dryRun bool,
+) (QuestionImportReport, error) {
+	var (
+		records []questionRecord
+		err     error
+	)
+
+	switch format {
+	case QuestionFormatJSON:
+		records, err = readJSONQuestions(r)
+	case QuestionFormatCSV:
+		records, err = readCSVQuestions(r)
+	case QuestionFormatYAML:
+		records, err = readYAMLQuestions(r)
+	default:
+		err = errUnknownQuestionFormat
+	}
+
+	if err != nil {
+		return QuestionImportReport{}, fmt.Errorf("%w: %w", errInvalidImport, err)
+	}
+
+	report := QuestionImportReport{DryRun: dryRun, Rows: make([]QuestionImportRow, len(records))}
+	seen := make(map[string]bool, len(records))
+
+	for idx, rec := range records {
+		row := &report.Rows[idx]
+		row.Row = idx + 1
+		row.ExternalID = rec.req.ExternalID
+
+		q, err := i.check(rec)
+		if err == nil && seen[q.ExternalID] {
+			err = errDuplicateExternalID
+		}
+
+		if err != nil {
+			if vErr, ok := asValidationError(err); ok {
+				row.Fields = vErr.Fields
+			} else {
+				row.Error = err.Error()
+			}
+
+			row.Status = importStatusInvalid
+			report.Invalid++
+
+			continue
+		}
+
+		seen[q.ExternalID] = true
+
+		i.upsert(ctx, row, q, authorID, dryRun)
+
+		switch row.Status {
+		case importStatusCreated:
+			report.Created++
+		case importStatusUpdated:
+			report.Updated++
+		case importStatusConflict:
+			report.Conflict++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func (i *QuestionImporter) check(rec questionRecord) (quiz.Question, error) {
+	if rec.err != nil {
+		return quiz.Question{}, rec.err
+	}
+
+	if err := validateStruct(i.validate, rec.req); err != nil {
+		return quiz.Question{}, err
+	}
+
+	// without an external ID a re-import could only duplicate the question
+	if rec.req.ExternalID == "" {
+		return quiz.Question{}, &validationError{Fields: []fieldError{{Field: "external_id", Message: "is required"}}}
+	}
+
+	q := rec.req.question()
+
+	return q, q.Validate()
+}
+
+func (i *QuestionImporter) upsert(ctx context.Context, row *QuestionImportRow, q quiz.Question, authorID int64, dryRun bool) {
+	id, err := i.service.FindByExternalID(ctx, q.ExternalID)
+
+	switch {
+	case errors.Is(err, quiz.ErrQuestionNotFound):
+		row.Status = importStatusCreated
+	case err != nil:
+		row.Status = importStatusFailed
+		row.Error = err.Error()
+
+		return
+	default:
+		row.Status = importStatusUpdated
+		row.QuestionID = id.String()
+	}
+
+	if dryRun {
+		if row.Status == importStatusUpdated {
+			i.checkEditable(ctx, row, id)
+		}
+
+		return
+	}
+
+	var rev quiz.Revision
+
+	if row.Status == importStatusCreated {
+		rev, err = i.service.Create(ctx, authorID, q)
+	} else {
+		rev, err = i.service.Edit(ctx, authorID, id, q)
+	}
+
+	if err == nil {
+		rev, err = i.service.Submit(ctx, authorID, rev.QuestionID)
+	}
+
+	switch {
+	case err == nil:
+		row.QuestionID = rev.QuestionID.String()
+		row.Revision = rev.Number
+	case errors.Is(err, quiz.ErrReviewState),
+		errors.Is(err, quiz.ErrRevisionConflict),
+		errors.Is(err, quiz.ErrExternalIDTaken):
+		row.Status = importStatusConflict
+		row.Error = err.Error()
+	default:
+		row.Status = importStatusFailed
+		row.Error = err.Error()
+	}
+}
+
+// checkEditable reports in dry runs the conflict Edit would fail with.
+// Questions from before authoring have no revisions and are editable.
+func (i *QuestionImporter) checkEditable(ctx context.Context, row *QuestionImportRow, id xid.ID) {
+	revisions, err := i.service.Revisions(ctx, id)
+
+	switch {
+	case errors.Is(err, quiz.ErrQuestionNotFound):
+	case err != nil:
+		row.Status = importStatusFailed
+		row.Error = err.Error()
+	case !revisions[0].Editable():
+		row.Status = importStatusConflict
+		row.Error = fmt.Errorf("%w: can't edit a revision %s", quiz.ErrReviewState, revisions[0].Status).Error()
+	}
+}
+
+// Export writes questions matching filter in the import shape. CSV numbers
+// options by column, so options come back with positional IDs.
+func (i *QuestionImporter) Export(ctx context.Context, w io.Writer, format QuestionFormat, filter quiz.QuestionFilter) error {
+	questions, err := i.service.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	reqs := make([]questionRequest, 0, len(questions))
+	for _, q := range questions {
+		reqs = append(reqs, newQuestionRequest(q))
+	}
+
+	switch format {
+	case QuestionFormatJSON:
+		data, err := sonic.ConfigFastest.MarshalIndent(reqs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal questions: %w", err)
+		}
+
+		_, err = fmt.Fprintln(w, string(data))
+
+		return err
+	case QuestionFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(reqs); err != nil {
+			return fmt.Errorf("marshal questions: %w", err)
+		}
+
+		return enc.Close()
+	case QuestionFormatCSV:
+		return writeCSVQuestions(w, reqs)
+	default:
+		return errUnknownQuestionFormat
+	}
+}
+
+func readJSONQuestions(r io.Reader) ([]questionRecord, error) {
+	var reqs []questionRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r).Decode(&reqs); err != nil {
+		return nil, fmt.Errorf("unmarshal questions: %w", err)
+	}
+
+	return newQuestionRecords(reqs)
+}
+
+func readYAMLQuestions(r io.Reader) ([]questionRecord, error) {
+	var reqs []questionRequest
+
+	if err := yaml.NewDecoder(r).Decode(&reqs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unmarshal questions: %w", err)
+	}
+
+	return newQuestionRecords(reqs)
+}
+
+func newQuestionRecords(reqs []questionRequest) ([]questionRecord, error) {
+	if len(reqs) > maxImportRows {
+		return nil, errTooManyImportRows
+	}
+
+	records := make([]questionRecord, 0, len(reqs))
+	for _, req := range reqs {
+		records = append(records, questionRecord{req: req})
+	}
+
+	return records, nil
+}
+
+// csvQuestionColumns precede option_1..option_N columns, which may be
+// followed by correct, tolerance and unit. For choice questions correct
+// holds option numbers separated by "|", for true/false "true" or "false",
+// for numeric ones the value.
+var csvQuestionColumns = []string{
+	"external_id", "type", "text", "category", "tags", "difficulty", "language", "media_kind", "media_url", "media_alt",
+}
+
+var csvAnswerColumns = []string{"correct", "tolerance", "unit"}
+
+const csvOptionPrefix = "option_"
+
+func readCSVQuestions(r io.Reader) ([]questionRecord, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	options := make(map[int]int) // option number to column
+
+	for idx, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		columns[name] = idx
+
+		if n, err := strconv.Atoi(strings.TrimPrefix(name, csvOptionPrefix)); err == nil && strings.HasPrefix(name, csvOptionPrefix) {
+			options[n] = idx
+		}
+	}
+
+	for _, name := range []string{"external_id", "type", "text", "category", "difficulty", "language", "correct"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header: missing %q column", name)
+		}
+	}
+
+	cr.FieldsPerRecord = len(header)
+
+	var records []questionRecord
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+
+		if len(records) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		cell := func(name string) string {
+			if idx, ok := columns[name]; ok {
+				return strings.TrimSpace(record[idx])
+			}
+
+			return ""
+		}
+
+		req, err := csvQuestion(cell, func(n int) string {
+			if idx, ok := options[n]; ok {
+				return strings.TrimSpace(record[idx])
+			}
+
+			return ""
+		})
+
+		records = append(records, questionRecord{req: req, err: err})
+	}
+
+	return records, nil
+}
+
+func csvQuestion(cell func(name string) string, option func(n int) string) (questionRequest, error) {
+	req := questionRequest{
+		ExternalID: cell("external_id"),
+		Type:       cell("type"),
+		Text:       cell("text"),
+		Category:   cell("category"),
+		Tags:       splitCSVList(cell("tags")),
+		Language:   cell("language"),
+	}
+
+	difficulty, err := strconv.Atoi(cell("difficulty"))
+	if err != nil {
+		return req, fmt.Errorf("difficulty: %q is not a number", cell("difficulty"))
+	}
+
+	req.Difficulty = difficulty
+
+	if kind := cell("media_kind"); kind != "" {
+		req.Media = &mediaRequest{Kind: kind, URL: cell("media_url"), Alt: cell("media_alt")}
+	}
+
+	correct := cell("correct")
+
+	switch quiz.QuestionType(req.Type) {
+	case quiz.TypeNumeric:
+		value, err := strconv.ParseFloat(correct, 64)
+		if err != nil {
+			return req, fmt.Errorf("correct: %q is not a number", correct)
+		}
+
+		var tolerance float64
+
+		if t := cell("tolerance"); t != "" {
+			if tolerance, err = strconv.ParseFloat(t, 64); err != nil {
+				return req, fmt.Errorf("tolerance: %q is not a number", t)
+			}
+		}
+
+		req.Numeric = &numericRequest{Value: value, Tolerance: tolerance, Unit: cell("unit")}
+	case quiz.TypeTrueFalse:
+		texts := []string{option(1), option(2)}
+		for n, id := range []string{quiz.OptionTrue, quiz.OptionFalse} {
+			if texts[n] == "" {
+				texts[n] = strings.ToUpper(id[:1]) + id[1:]
+			}
+
+			req.Options = append(req.Options, optionRequest{ID: id, Text: texts[n], Correct: correct == id})
+		}
+	default:
+		picked := make(map[string]bool)
+		for _, n := range splitCSVList(correct) {
+			if option(atoiOrZero(n)) == "" {
+				return req, fmt.Errorf("correct: option %s is not among the options", n)
+			}
+
+			picked[n] = true
+		}
+
+		for n := 1; n <= quiz.MaxOptions; n++ {
+			if text := option(n); text != "" {
+				id := strconv.Itoa(n)
+				req.Options = append(req.Options, optionRequest{ID: id, Text: text, Correct: picked[id]})
+			}
+		}
+	}
+
+	return req, nil
+}
+
+func writeCSVQuestions(w io.Writer, reqs []questionRequest) error {
+	maxOptions := 0
+	for _, req := range reqs {
+		if req.Type != string(quiz.TypeTrueFalse) && len(req.Options) > maxOptions {
+			maxOptions = len(req.Options)
+		}
+	}
+
+	if maxOptions < 2 {
+		maxOptions = 2
+	}
+
+	header := append([]string{}, csvQuestionColumns...)
+	for n := 1; n <= maxOptions; n++ {
+		header = append(header, csvOptionPrefix+strconv.Itoa(n))
+	}
+
+	header = append(header, csvAnswerColumns...)
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		record := []string{
+			req.ExternalID,
+			req.Type,
+			req.Text,
+			req.Category,
+			strings.Join(req.Tags, csvListSeparator),
+			strconv.Itoa(req.Difficulty),
+			req.Language,
+			"", "", "",
+		}
+
+		if req.Media != nil {
+			record[7], record[8], record[9] = req.Media.Kind, req.Media.URL, req.Media.Alt
+		}
+
+		options := make([]string, maxOptions)
+		answer := make([]string, len(csvAnswerColumns))
+
+		switch {
+		case req.Numeric != nil:
+			answer[0] = strconv.FormatFloat(req.Numeric.Value, 'f', -1, 64)
+			answer[1] = strconv.FormatFloat(req.Numeric.Tolerance, 'f', -1, 64)
+			answer[2] = req.Numeric.Unit
+		case req.Type == string(quiz.TypeTrueFalse):
+			// the true option goes first whatever the stored order
+			sorted := append([]optionRequest{}, req.Options...)
+			sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID == quiz.OptionTrue })
+
+			for n, o := range sorted {
+				options[n] = o.Text
+				if o.Correct {
+					answer[0] = o.ID
+				}
+			}
+		default:
+			var correct []string
+
+			for n, o := range req.Options {
+				options[n] = o.Text
+				if o.Correct {
+					correct = append(correct, strconv.Itoa(n+1))
+				}
+			}
+
+			answer[0] = strings.Join(correct, csvListSeparator)
+		}
+
+		record = append(append(record, options...), answer...)
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func splitCSVList(cell string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(cell, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s) // nolint zero is never an option number
+
+	return n
+}
+
+func (h *Questions) importQuestions(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.writeServiceErr(w, r, errUnsupportedQuestionType)
+		return
+	}
+
+	format, err := ParseQuestionFormat(mediaType)
+	if err != nil {
+		h.writeServiceErr(w, r, errUnsupportedQuestionType)
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	if err != nil && r.URL.Query().Has("dry_run") {
+		h.writeErr(w, r, fmt.Errorf("dry_run: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+
+	report, err := h.importer.Import(r.Context(), body, format, data.User.ID, dryRun)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, report)
+}
+
+// exportQuestions takes format, category, language and status query
+// parameters, published questions in JSON by default.
+func (h *Questions) exportQuestions(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, false); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := QuestionFormatJSON
+
+	if name := query.Get("format"); name != "" {
+		var err error
+		if format, err = ParseQuestionFormat(name); err != nil {
+			h.writeErr(w, r, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter := quiz.QuestionFilter{
+		Status:   quiz.QuestionStatus(query.Get("status")),
+		Category: query.Get("category"),
+		Language: query.Get("language"),
+	}
+
+	switch filter.Status {
+	case "":
+		filter.Status = quiz.QuestionPublished
+	case quiz.QuestionPublished, quiz.QuestionRetired:
+	default:
+		h.writeErr(w, r, fmt.Errorf("unknown status %q", filter.Status), http.StatusBadRequest)
+		return
+	}
+
+	// render first, a failure half way through can't change the status any more
+	var buf strings.Builder
+
+	if err := h.importer.Export(r.Context(), &buf, format, filter); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="questions.%s"`, format))
+
+	_, _ = io.WriteString(w, buf.String()) // nolint client is gone if write fails
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"go.uber.org/zap"
+)
+
+const (
+	sessionStatusActive   = "active"
+	sessionStatusFinished = "finished"
+)
+
+type optionView struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type mediaView struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	Alt  string `json:"alt,omitempty"`
+}
+
+type questionView struct {
+	Index   int          `json:"index"`
+	Type    string       `json:"type"`
+	Text    string       `json:"text"`
+	Media   *mediaView   `json:"media,omitempty"`
+	Options []optionView `json:"options,omitempty"`
+	Unit    string       `json:"unit,omitempty"`
+}
+
+type answerView struct {
+	Correct  bool    `json:"correct"`
+	Credit   float64 `json:"credit"`
+	Points   int     `json:"points"`
+	TimedOut bool    `json:"timed_out"`
+}
+
+type pointsView struct {
+	Base   int `json:"base"`
+	Speed  int `json:"speed"`
+	Streak int `json:"streak"`
+}
+
+// sessionView never exposes correct options of unanswered questions.
+type sessionView struct {
+	ID          string        `json:"id"`
+	Mode        string        `json:"mode"`
+	Status      string        `json:"status"`
+	Total       int           `json:"total"`
+	Score       int           `json:"score"`
+	MaxScore    int           `json:"max_score"`
+	Streak      int           `json:"streak"`
+	TimeLimitMS int64         `json:"time_limit_ms"`
+	RemainingMS int64         `json:"remaining_ms"`
+	Question    *questionView `json:"question,omitempty"`
+	Answers     []answerView  `json:"answers"`
+}
+
+type sessionResponse struct {
+	Session sessionView `json:"session"`
+}
+
+// startRequest picks the deck of a new session, all fields are optional.
+// The body may be empty.
+type startRequest struct {
+	Category   string `json:"category" validate:"max=64"`
+	Difficulty int    `json:"difficulty" validate:"min=0,max=5"`
+	Language   string `json:"language" validate:"omitempty,len=2"`
+}
+
+type categoryView struct {
+	Name      string `json:"name"`
+	Questions int    `json:"questions"`
+}
+
+type categoriesResponse struct {
+	Categories []categoryView `json:"categories"`
+}
+
+// answerRequest has option_ids for choice questions or number for numeric
+// ones, neither when the timer ran out.
+type answerRequest struct {
+	Question  *int     `json:"question" validate:"required,min=0"`
+	OptionIDs []string `json:"option_ids" validate:"max=10,dive,required,max=64"`
+	Number    *float64 `json:"number"`
+}
+
+type answerResponse struct {
+	Correct          bool        `json:"correct"`
+	Credit           float64     `json:"credit"`
+	Points           int         `json:"points"`
+	Breakdown        pointsView  `json:"breakdown"`
+	TimedOut         bool        `json:"timed_out"`
+	CorrectOptionIDs []string    `json:"correct_option_ids,omitempty"`
+	CorrectNumber    *float64    `json:"correct_number,omitempty"`
+	Session          sessionView `json:"session"`
+}
+
+type Quiz struct {
+	responder
+
+	service      quiz.Service
+	translations quiz.TranslationService
+	auth         *TelegramAuth
+	validate     *validator.Validate
+}
+
+func NewQuiz(
+	service quiz.Service,
+	translations quiz.TranslationService,
+	auth *TelegramAuth,
+	logger *zap.Logger,
+) *Quiz {
+	return &Quiz{
+		responder:    responder{logger: logger},
+		service:      service,
+		translations: translations,
+		auth:         auth,
+		validate:     newValidate(),
+	}
+}
+
+func (h *Quiz) Register(r *mux.Router) {
+	r.HandleFunc("/quiz/categories", h.categories).Name("quiz_categories").Methods("GET")
+	r.HandleFunc("/quiz/sessions", h.start).Name("start_quiz_session").Methods("POST")
+	r.HandleFunc("/quiz/sessions/current", h.current).Name("current_quiz_session").Methods("GET")
+	r.HandleFunc("/quiz/sessions/{id}/answers", h.answer).Name("answer_quiz_question").Methods("POST")
+}
+
+func (h *Quiz) start(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	var req startRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	s, err := h.service.Start(r.Context(), data.User.ID, quiz.DeckRequest{
+		Category:   req.Category,
+		Difficulty: req.Difficulty,
+		Language:   req.Language,
+	})
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	v, err := localizedSessionView(r.Context(), h.translations, s, LocalizerFromContext(r.Context()).Locales())
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, sessionResponse{Session: v})
+}
+
+// categories lists categories in the "language" query parameter, or in all
+// languages without it.
+func (h *Quiz) categories(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.user(r, false); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	categories, err := h.service.Categories(r.Context(), r.URL.Query().Get("language"))
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	resp := categoriesResponse{Categories: make([]categoryView, 0, len(categories))}
+	for _, c := range categories {
+		resp.Categories = append(resp.Categories, categoryView{Name: c.Name, Questions: c.Questions})
+	}
+
+	h.writeResponse(w, resp)
+}
+
+func (h *Quiz) current(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, false)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	s, err := h.service.Current(r.Context(), data.User.ID)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	v, err := localizedSessionView(r.Context(), h.translations, s, LocalizerFromContext(r.Context()).Locales())
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, sessionResponse{Session: v})
+}
+
+func (h *Quiz) answer(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.user(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	var req answerRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	res, err := h.service.Answer(
+		r.Context(),
+		data.User.ID,
+		id,
+		*req.Question,
+		quiz.Response{OptionIDs: req.OptionIDs, Number: req.Number},
+	)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	v, err := localizedSessionView(
+		r.Context(),
+		h.translations,
+		res.Session,
+		LocalizerFromContext(r.Context()).Locales(),
+	)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	resp := answerResponse{
+		Correct:   res.Answer.Correct,
+		Credit:    res.Answer.Credit,
+		Points:    res.Answer.Points,
+		Breakdown: newPointsView(res.Answer.Breakdown),
+		TimedOut:  res.Answer.TimedOut,
+		Session:   v,
+	}
+
+	if res.Question.Type == quiz.TypeNumeric {
+		resp.CorrectNumber = &res.Question.Numeric.Value
+	} else {
+		resp.CorrectOptionIDs = res.Question.CorrectOptionIDs()
+	}
+
+	h.writeResponse(w, resp)
+}
+
+func newSessionView(s quiz.Session, now time.Time) sessionView {
+	v := sessionView{
+		ID:          s.ID.String(),
+		Mode:        s.Mode,
+		Status:      sessionStatusActive,
+		Total:       len(s.Questions),
+		Score:       s.Score,
+		MaxScore:    s.MaxScore(),
+		Streak:      s.Streak(),
+		TimeLimitMS: s.TimeLimit.Milliseconds(),
+		Answers:     make([]answerView, 0, len(s.Answers)),
+	}
+
+	for _, a := range s.Answers {
+		v.Answers = append(v.Answers, answerView{
+			Correct:  a.Correct,
+			Credit:   a.Credit,
+			Points:   a.Points,
+			TimedOut: a.TimedOut,
+		})
+	}
+
+	if s.Finished() {
+		v.Status = sessionStatusFinished
+		return v
+	}
+
+	if q, idx, ok := s.Current(); ok {
+		v.Question = newQuestionView(q, idx)
+
+		if remaining := s.Deadline().Sub(now); remaining > 0 {
+			v.RemainingMS = remaining.Milliseconds()
+		}
+	}
+
+	return v
+}
+
+func newPointsView(p quiz.Points) pointsView {
+	return pointsView{Base: p.Base, Speed: p.Speed, Streak: p.Streak}
+}
+
+func newQuestionView(q quiz.Question, idx int) *questionView {
+	v := &questionView{
+		Index:   idx,
+		Type:    string(q.Type),
+		Text:    q.Text,
+		Options: make([]optionView, 0, len(q.Options)),
+	}
+
+	for _, o := range q.Options {
+		v.Options = append(v.Options, optionView{ID: o.ID, Text: o.Text})
+	}
+
+	if q.Media != nil {
+		v.Media = &mediaView{Kind: string(q.Media.Kind), URL: q.Media.URL, Alt: q.Media.Alt}
+	}
+
+	if q.Numeric != nil {
+		v.Unit = q.Numeric.Unit
+	}
+
+	return v
+}
+
+// localizedSessionView shows the current question in the first of locales it
+// is available in.
+func localizedSessionView(
+	ctx context.Context,
+	translations quiz.TranslationService,
+	s quiz.Session,
+	locales []string,
+) (sessionView, error) {
+	v := newSessionView(s, time.Now())
+	if v.Question == nil {
+		return v, nil
+	}
+
+	q, idx, _ := s.Current()
+
+	q, err := translations.Localize(ctx, q, locales)
+	if err != nil {
+		return sessionView{}, fmt.Errorf("localize question: %w", err)
+	}
+
+	v.Question = newQuestionView(q, idx)
+
+	return v, nil
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/player"
+	"00-go-base-tpl-sv/internal/quiz"
+	"00-go-base-tpl-sv/internal/telegram"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"go.uber.org/zap"
+)
+
+const (
+	errorTypePrefix    = "/errors/"
+	errorMessagePrefix = "error."
+)
+
+// errorResponse has the message for developers and the title, if there is
+// one, in the user's language.
+type errorResponse struct {
+	Type      string       `json:"type"`
+	Code      string       `json:"code"`
+	Title     string       `json:"title,omitempty"`
+	Message   string       `json:"message"`
+	Fields    []fieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+var errorMappings = []errorMapping{
+	{err: player.ErrNotFound, status: http.StatusNotFound, code: "player_not_found"},
+	{err: player.ErrConflict, status: http.StatusConflict, code: "player_conflict"},
+	{err: player.ErrVersionMismatch, status: http.StatusConflict, code: "version_mismatch"},
+	{err: player.ErrIDMismatch, status: http.StatusBadRequest, code: "id_mismatch"},
+	{err: player.ErrEmptyRequest, status: http.StatusBadRequest, code: "empty_request"},
+	{err: errPreconditionFailed, status: http.StatusPreconditionFailed, code: "precondition_failed"},
+	{err: errUnsupportedContentType, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+	{err: errMergePatchNotObject, status: http.StatusBadRequest, code: "invalid_merge_patch"},
+	{err: errUnsupportedImportType, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+	{err: errInvalidImport, status: http.StatusBadRequest, code: "invalid_import"},
+	{err: errUnsupportedQuestionType, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+	{err: quiz.ErrNotFound, status: http.StatusNotFound, code: "quiz_session_not_found"},
+	{err: quiz.ErrNoQuestions, status: http.StatusServiceUnavailable, code: "no_questions"},
+	{err: quiz.ErrFinished, status: http.StatusConflict, code: "quiz_session_finished"},
+	{err: quiz.ErrQuestionMismatch, status: http.StatusConflict, code: "question_mismatch"},
+	{err: quiz.ErrInvalidOption, status: http.StatusBadRequest, code: "invalid_option"},
+	{err: quiz.ErrInvalidResponse, status: http.StatusBadRequest, code: "invalid_response"},
+	{err: quiz.ErrVersionMismatch, status: http.StatusConflict, code: "quiz_session_conflict"},
+	{err: quiz.ErrInvalidDeck, status: http.StatusBadRequest, code: "invalid_deck"},
+	{err: quiz.ErrQuestionNotFound, status: http.StatusNotFound, code: "question_not_found"},
+	{err: quiz.ErrReviewState, status: http.StatusConflict, code: "invalid_review_state"},
+	{err: quiz.ErrSelfReview, status: http.StatusForbidden, code: "self_review"},
+	{err: quiz.ErrRevisionConflict, status: http.StatusConflict, code: "question_revision_conflict"},
+	{err: quiz.ErrExternalIDTaken, status: http.StatusConflict, code: "external_id_conflict"},
+	{err: quiz.ErrRateLimited, status: http.StatusTooManyRequests, code: "rate_limited"},
+	{err: quiz.ErrOwnQuestion, status: http.StatusForbidden, code: "own_question"},
+	{err: quiz.ErrInvalidVote, status: http.StatusBadRequest, code: "invalid_vote"},
+	{err: quiz.ErrVotingClosed, status: http.StatusConflict, code: "voting_closed"},
+	{err: quiz.ErrAlreadyReported, status: http.StatusConflict, code: "already_reported"},
+	{err: quiz.ErrTranslationNotFound, status: http.StatusNotFound, code: "translation_not_found"},
+	{err: telegram.ErrInitDataMissing, status: http.StatusUnauthorized, code: "unauthorized"},
+	{err: telegram.ErrInitDataInvalid, status: http.StatusUnauthorized, code: "unauthorized"},
+	{err: telegram.ErrInitDataExpired, status: http.StatusUnauthorized, code: "init_data_expired"},
+	{err: errInvalidNonce, status: http.StatusForbidden, code: "invalid_nonce"},
+	{err: errForbidden, status: http.StatusForbidden, code: "forbidden"},
+}
+
+type responder struct {
+	logger *zap.Logger
+}
+
+func (rs responder) writeServiceErr(w http.ResponseWriter, r *http.Request, err error) {
+	if vErr, ok := asValidationError(err); ok {
+		rs.writeProblem(w, r, http.StatusUnprocessableEntity, errorResponse{
+			Code:    "validation_failed",
+			Message: "request validation failed",
+			Fields:  vErr.Fields,
+		})
+
+		return
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			rs.writeProblem(w, r, m.status, errorResponse{Code: m.code, Message: err.Error()})
+			return
+		}
+	}
+
+	LoggerFromContext(r.Context(), rs.logger).Error("internal error", zap.Error(err))
+
+	rs.writeProblem(w, r, http.StatusInternalServerError, errorResponse{
+		Code:    "internal_error",
+		Message: http.StatusText(http.StatusInternalServerError),
+	})
+}
+
+func (rs responder) writeErr(w http.ResponseWriter, r *http.Request, err error, status int) {
+	rs.writeProblem(w, r, status, errorResponse{
+		Code:    statusCode(status),
+		Message: err.Error(),
+	})
+}
+
+func (rs responder) writeProblem(w http.ResponseWriter, r *http.Request, status int, resp errorResponse) {
+	resp.Type = errorTypePrefix + resp.Code
+	resp.Title = LocalizerFromContext(r.Context()).Message(errorMessagePrefix + resp.Code)
+	resp.RequestID = RequestIDFromContext(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	rs.writeResponse(w, resp)
+}
+
+func (rs responder) writeResponse(w http.ResponseWriter, v interface{}) {
+	data, err := sonic.ConfigFastest.Marshal(v)
+	if err != nil {
+		rs.logger.Error("json marshal error", zap.Error(err))
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		rs.logger.Error("write error", zap.Error(err))
+	}
+}
+
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
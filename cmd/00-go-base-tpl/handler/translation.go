@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"go.uber.org/zap"
+)
+
+// translationRequest has option texts by option ID, options left out keep
+// the text of the question.
+type translationRequest struct {
+	Text     string            `json:"text" validate:"required,max=1024"`
+	Options  map[string]string `json:"options" validate:"max=10"`
+	MediaAlt string            `json:"media_alt" validate:"max=256"`
+	Unit     string            `json:"unit" validate:"max=32"`
+}
+
+type translationView struct {
+	Locale    string            `json:"locale"`
+	Revision  int               `json:"revision"`
+	Text      string            `json:"text"`
+	Options   map[string]string `json:"options,omitempty"`
+	MediaAlt  string            `json:"media_alt,omitempty"`
+	Unit      string            `json:"unit,omitempty"`
+	AuthorID  int64             `json:"author_id"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type translationResponse struct {
+	Translation translationView `json:"translation"`
+}
+
+type translationsResponse struct {
+	Translations []translationView `json:"translations"`
+}
+
+// Translations manages translations of published questions, every endpoint
+// requires the admin role.
+type Translations struct {
+	responder
+
+	service  quiz.TranslationService
+	auth     *TelegramAuth
+	validate *validator.Validate
+}
+
+func NewTranslations(service quiz.TranslationService, auth *TelegramAuth, logger *zap.Logger) *Translations {
+	return &Translations{
+		responder: responder{logger: logger},
+		service:   service,
+		auth:      auth,
+		validate:  newValidate(),
+	}
+}
+
+func (h *Translations) Register(r *mux.Router) {
+	r.HandleFunc("/admin/questions/{id}/translations", h.list).Name("question_translations").Methods("GET")
+	r.HandleFunc("/admin/questions/{id}/translations/{locale}", h.put).Name("put_question_translation").Methods("PUT")
+	r.HandleFunc("/admin/questions/{id}/translations/{locale}", h.delete).
+		Name("delete_question_translation").
+		Methods("DELETE")
+}
+
+func (h *Translations) list(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, false); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	translations, err := h.service.List(r.Context(), id)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	resp := translationsResponse{Translations: make([]translationView, 0, len(translations))}
+	for _, t := range translations {
+		resp.Translations = append(resp.Translations, newTranslationView(t))
+	}
+
+	h.writeResponse(w, resp)
+}
+
+func (h *Translations) put(w http.ResponseWriter, r *http.Request) {
+	data, err := h.auth.admin(r, true)
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	var req translationRequest
+
+	if err := sonic.ConfigFastest.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, r, fmt.Errorf("unmarshal request body: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStruct(h.validate, req); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	t, err := h.service.Put(r.Context(), data.User.ID, quiz.Translation{
+		QuestionID: id,
+		Locale:     mux.Vars(r)["locale"],
+		Text:       req.Text,
+		Options:    req.Options,
+		MediaAlt:   req.MediaAlt,
+		Unit:       req.Unit,
+	})
+	if err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, translationResponse{Translation: newTranslationView(t)})
+}
+
+func (h *Translations) delete(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.auth.admin(r, true); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	id, err := xid.FromString(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeErr(w, r, fmt.Errorf("parse id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id, mux.Vars(r)["locale"]); err != nil {
+		h.writeServiceErr(w, r, err)
+		return
+	}
+
+	h.writeResponse(w, struct{}{})
+}
+
+func newTranslationView(t quiz.Translation) translationView {
+	return translationView{
+		Locale:    t.Locale,
+		Revision:  t.Revision,
+		Text:      t.Text,
+		Options:   t.Options,
+		MediaAlt:  t.MediaAlt,
+		Unit:      t.Unit,
+		AuthorID:  t.AuthorID,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
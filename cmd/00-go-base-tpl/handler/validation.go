@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type validationError struct {
+	Fields []fieldError
+}
+
+func (e *validationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "schema"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+
+			if name != "" {
+				return name
+			}
+		}
+
+		return f.Name
+	})
+
+	// required accepts whitespace-only strings
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	return v
+}
+
+// validateStruct checks v against its validate tags. With fields given only
+// those (struct field names) are checked, which is what partial updates need.
+func validateStruct(v *validator.Validate, s interface{}, fields ...string) error {
+	var err error
+
+	if len(fields) > 0 {
+		err = v.StructPartial(s, fields...)
+	} else {
+		err = v.Struct(s)
+	}
+
+	return toValidationError(err)
+}
+
+func toValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	out := &validationError{Fields: make([]fieldError, 0, len(errs))}
+	for _, fe := range errs {
+		out.Fields = append(out.Fields, fieldError{
+			Field:   fe.Field(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+
+	return out
+}
+
+// asValidationError returns the field errors of request validation and of
+// quiz rules, both are answered the same way.
+func asValidationError(err error) (*validationError, bool) {
+	var vErr *validationError
+	if errors.As(err, &vErr) {
+		return vErr, true
+	}
+
+	var qErr *quiz.ValidationError
+	if !errors.As(err, &qErr) {
+		return nil, false
+	}
+
+	vErr = &validationError{Fields: make([]fieldError, 0, len(qErr.Fields))}
+	for _, f := range qErr.Fields {
+		vErr.Fields = append(vErr.Fields, fieldError{Field: f.Field, Message: f.Message})
+	}
+
+	return vErr, true
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed %q check", fe.Tag())
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"00-go-base-tpl-sv/internal/frontend"
+	"00-go-base-tpl-sv/internal/i18n"
+	"00-go-base-tpl-sv/internal/quiz"
+	"00-go-base-tpl-sv/internal/telegram"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	initDataFormField = "init_data"
+	initDataAuthType  = "tma "
+)
+
+type bootstrapProfile struct {
+	TelegramID int64  `json:"telegram_id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name,omitempty"`
+	Username   string `json:"username,omitempty"`
+	PhotoURL   string `json:"photo_url,omitempty"`
+	IsPremium  bool   `json:"is_premium,omitempty"`
+	IsAdmin    bool   `json:"is_admin,omitempty"`
+}
+
+// bootstrapPayload is rendered into the page so the client can start
+// without asking the API who the user is.
+type bootstrapPayload struct {
+	Profile bootstrapProfile `json:"profile"`
+	Locale  string           `json:"locale"`
+	// Messages are the texts of the page in the user's language.
+	Messages map[string]string `json:"messages"`
+	Flags    map[string]bool   `json:"flags"`
+	// Session is the unfinished game of the user, if any.
+	Session *sessionView `json:"session"`
+	Nonce   string       `json:"nonce"`
+}
+
+type WebApp struct {
+	responder
+
+	frontend     *frontend.Frontend
+	auth         *TelegramAuth
+	quiz         quiz.Service
+	translations quiz.TranslationService
+	catalog      *i18n.Catalog
+	flags        map[string]bool
+}
+
+func NewWebApp(
+	frontend *frontend.Frontend,
+	auth *TelegramAuth,
+	quiz quiz.Service,
+	translations quiz.TranslationService,
+	catalog *i18n.Catalog,
+	features []string,
+	logger *zap.Logger,
+) *WebApp {
+	flags := make(map[string]bool, len(features))
+	for _, f := range features {
+		flags[f] = true
+	}
+
+	return &WebApp{
+		responder:    responder{logger: logger},
+		frontend:     frontend,
+		auth:         auth,
+		quiz:         quiz,
+		translations: translations,
+		catalog:      catalog,
+		flags:        flags,
+	}
+}
+
+func (h *WebApp) Register(r *mux.Router) {
+	r.HandleFunc("/", h.index).Name("home").Methods("GET", "POST")
+	r.PathPrefix(frontend.StaticPrefix).
+		Handler(http.StripPrefix(frontend.StaticPrefix, h.frontend.Assets())).
+		Name("static").
+		Methods("GET", "HEAD")
+}
+
+// index renders the page. Telegram passes initData to the page script only,
+// so the first GET renders a shell which re-posts itself with initData in the
+// form body (a query would leak it to traces) to get the bootstrap payload.
+func (h *WebApp) index(w http.ResponseWriter, r *http.Request) {
+	var payload *bootstrapPayload
+
+	if r.Method == http.MethodPost || r.Header.Get("Authorization") != "" {
+		data, err := h.auth.user(r, false)
+		if err != nil {
+			h.writeServiceErr(w, r, err)
+			return
+		}
+
+		payload, err = h.bootstrap(r.Context(), data)
+		if err != nil {
+			h.writeServiceErr(w, r, err)
+			return
+		}
+	}
+
+	// render into a buffer first so a template error doesn't end up as half a page
+	var buf bytes.Buffer
+
+	if err := h.frontend.Render(&buf, "index.html", struct{ Bootstrap *bootstrapPayload }{payload}); err != nil {
+		h.writeServiceErr(w, r, fmt.Errorf("render index: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the page links hashed assets and may carry user data, so never reuse it
+	w.Header().Set("Cache-Control", "no-store")
+
+	_, _ = buf.WriteTo(w) // nolint client is gone if write fails
+}
+
+func (h *WebApp) bootstrap(ctx context.Context, data telegram.InitData) (*bootstrapPayload, error) {
+	nonce, err := h.auth.nonces.issue(data.User.ID)
+	if err != nil {
+		return nil, fmt.Errorf("issue nonce: %w", err)
+	}
+
+	// the form post has no Authorization header, so the localizer of the
+	// request may not know the user's language
+	l := h.catalog.Localizer(data.User.LanguageCode)
+
+	var session *sessionView
+
+	s, err := h.quiz.Current(ctx, data.User.ID)
+	switch {
+	case errors.Is(err, quiz.ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("current quiz session: %w", err)
+	default:
+		v, err := localizedSessionView(ctx, h.translations, s, l.Locales())
+		if err != nil {
+			return nil, err
+		}
+
+		session = &v
+	}
+
+	return &bootstrapPayload{
+		Profile: bootstrapProfile{
+			TelegramID: data.User.ID,
+			FirstName:  data.User.FirstName,
+			LastName:   data.User.LastName,
+			Username:   data.User.Username,
+			PhotoURL:   data.User.PhotoURL,
+			IsPremium:  data.User.IsPremium,
+			IsAdmin:    h.auth.isAdmin(data.User.ID),
+		},
+		Locale:   l.Locale(),
+		Messages: l.Messages(),
+		Flags:    h.flags,
+		Session:  session,
+		Nonce:    nonce,
+	}, nil
+}
+
+// initDataFromRequest takes initData from "Authorization: tma <initData>",
+// which API calls use, or from the form the page posts.
+func initDataFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, initDataAuthType) {
+		return strings.TrimPrefix(auth, initDataAuthType)
+	}
+
+	return r.PostFormValue(initDataFormField)
+}
@@ -1,27 +1,78 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"go.uber.org/automaxprocs/maxprocs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-func MustReadConfig() *Config {
-	config, err := ReadConfig()
+// exitInvalidSetup is the exit code for an unreadable config or a logger that
+// can't be built, before anything could be logged.
+const exitInvalidSetup = 2
+
+// MustReadConfig returns the validated config and the command args. Asking
+// for help exits right after printing usage.
+func MustReadConfig() (*Config, []string) {
+	config, args, err := ReadConfig(os.Args[0], os.Args[1:], os.Stderr)
+	if errors.Is(err, pflag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	if err != nil {
+		exitSetup("read config: %s", err)
+	}
+
+	if err := config.Validate(commandName(args)); err != nil {
+		exitSetup("invalid config:\n%s", err)
+	}
+
+	return config, args
+}
+
+func CreateLogger(config *Config) (*zap.Logger, error) {
+	zapConfig := zap.NewProductionConfig()
+
+	if config.App.Debug {
+		zapConfig = zap.NewDevelopmentConfig()
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	zapConfig.EncoderConfig.TimeKey = "time"
+	zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	log, err := zapConfig.Build()
 	if err != nil {
-		panic(fmt.Sprintf("read config: %s", err))
+		return nil, fmt.Errorf("build logger: %w", err)
 	}
 
-	return config
+	return log.With(zap.String("service", config.App.ServiceName)), nil
 }
 
-//func CreateLogger(config *Config) *zap.Logger {
-//	if config.App.Debug {
-//		return logger.NewZapWithOptions(
-//			logger.WithDebug(true),
-//			logger.WithLogLevel(zapcore.DebugLevel),
-//		)
-//	}
-//
-//	return logger.NewZapWithOptions(
-//		logger.WithLogLevel(zapcore.InfoLevel),
-//	)
-//} todo
+func MustCreateLogger(config *Config) *zap.Logger {
+	log, err := CreateLogger(config)
+	if err != nil {
+		exitSetup("create logger: %s", err)
+	}
+
+	return log
+}
+
+// exitSetup reports to stderr, as there is no logger yet, without the stack
+// trace of a panic: these are operator errors.
+func exitSetup(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...) // nolint nothing to do if stderr is gone
+	os.Exit(exitInvalidSetup)
+}
+
+func mustSetMaxProcs(log *zap.Logger) {
+	_, err := maxprocs.Set(maxprocs.Logger(log.Sugar().Infof))
+	if err != nil {
+		log.Panic("set maxprocs", zap.Error(err))
+	}
+}
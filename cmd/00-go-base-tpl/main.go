@@ -2,33 +2,36 @@ package main
 
 import (
 	"context"
+	"os"
 	"os/signal"
 	"syscall"
+
+	"go.uber.org/zap"
 )
 
 func main() {
 	var (
 		ctx, stop = signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
-		config = MustReadConfig()
+		config, args = MustReadConfig()
 
-		//log = CreateLogger(config)
+		log = MustCreateLogger(config)
 	)
 
-	//zap.RedirectStdLog(log) // todo
-
-	//defer log.Sync() // nolint skip sync errors as non-important
-	defer stop()
+	restoreStdLog := zap.RedirectStdLog(log)
 
-	//mustSetMaxProcs(log)
+	mustSetMaxProcs(log)
 
-	//app, err := NewAppBuilder().SetConfig(config).SetLog(log).Build()
-	app, err := NewAppBuilder().SetConfig(config).Build()
+	err := RunCommand(ctx, config, log, args)
 	if err != nil {
-		//log.Panic("app build failed", zap.Error(err))
+		log.Error("application failed", zap.Error(err))
 	}
 
-	if err := app.Run(ctx); err != nil {
-		//log.Panic("application run error", zap.Error(err))
+	stop()
+	restoreStdLog()
+	_ = log.Sync() // nolint skip sync errors as non-important
+
+	if err != nil {
+		os.Exit(1)
 	}
 }
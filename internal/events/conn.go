@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const exchangeKind = "topic"
+
+type Config struct {
+	DSN string
+	// Exchange is the topic exchange every event is published to.
+	Exchange          string
+	FallbackDelay     time.Duration
+	MaxFailedAttempts int
+	Heartbeat         time.Duration
+}
+
+// Conn is a RabbitMQ connection. It doesn't reconnect: a lost connection
+// fails readiness and ends consumers, so Kubernetes restarts the pod.
+type Conn struct {
+	config Config
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+}
+
+func NewConn(config Config) *Conn {
+	return &Conn{config: config}
+}
+
+// Connect dials up to MaxFailedAttempts times, FallbackDelay apart, and
+// declares the exchange.
+func (c *Conn) Connect(ctx context.Context) error {
+	var (
+		conn *amqp.Connection
+		err  error
+	)
+
+	for attempt := 1; ; attempt++ {
+		conn, err = amqp.DialConfig(c.config.DSN, amqp.Config{Heartbeat: c.config.Heartbeat})
+		if err == nil {
+			break
+		}
+
+		if attempt >= c.config.MaxFailedAttempts {
+			return fmt.Errorf("dial after %d attempts: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("dial: %w", context.Cause(ctx))
+		case <-time.After(c.config.FallbackDelay):
+		}
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.ExchangeDeclare(c.config.Exchange, exchangeKind, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("declare exchange %s: %w", c.config.Exchange, err)
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Conn) Close(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil
+	}
+
+	return c.conn.Close()
+}
+
+// Check fails when the connection is not open, for readiness probes.
+func (c *Conn) Check(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil || c.conn.IsClosed() {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+func (c *Conn) channel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, ErrClosed
+	}
+
+	return c.conn.Channel()
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"00-go-base-tpl-sv/internal/metrics"
+	"00-go-base-tpl-sv/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const consumerPrefetch = 10
+
+// Handler processes a delivery, the consumer acks it when Handler returns nil.
+type Handler func(ctx context.Context, d amqp.Delivery) error
+
+// Consumer reads a durable queue bound to the exchange of the connection.
+type Consumer struct {
+	conn    *Conn
+	metrics *metrics.AMQP
+}
+
+func NewConsumer(conn *Conn, metrics *metrics.AMQP) *Consumer {
+	return &Consumer{conn: conn, metrics: metrics}
+}
+
+// Consume declares queue, binds it by bindingKey and hands deliveries to h
+// until ctx is done. A failed delivery is requeued once, then dropped. It
+// returns an error when the broker closes the channel.
+func (c *Consumer) Consume(ctx context.Context, queue, bindingKey string, h Handler) error {
+	ch, err := c.conn.channel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare queue %s: %w", queue, err)
+	}
+
+	if err := ch.QueueBind(queue, bindingKey, c.conn.config.Exchange, false, nil); err != nil {
+		return fmt.Errorf("bind queue %s: %w", queue, err)
+	}
+
+	if err := ch.Qos(consumerPrefetch, 0, false); err != nil {
+		return fmt.Errorf("set qos: %w", err)
+	}
+
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", queue, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("consume %s: %w", queue, ErrClosed)
+			}
+
+			if err := c.handle(ctx, queue, d, h); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle returns only acknowledgement errors, those of h are settled by a
+// nack.
+func (c *Consumer) handle(ctx context.Context, queue string, d amqp.Delivery, h Handler) (err error) {
+	ctx, span := tracing.StartConsume(ctx, queue, d)
+
+	start := time.Now()
+	handleErr := h(ctx, d)
+
+	defer func() {
+		c.metrics.Consumed(queue, handleErr, time.Since(start))
+		tracing.Finish(span, errors.Join(handleErr, err))
+	}()
+
+	if handleErr != nil {
+		if err := d.Nack(false, !d.Redelivered); err != nil {
+			return fmt.Errorf("nack %s: %w", d.MessageId, err)
+		}
+
+		return nil
+	}
+
+	if err := d.Ack(false); err != nil {
+		return fmt.Errorf("ack %s: %w", d.MessageId, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package events
+
+import (
+	"errors"
+)
+
+var ErrClosed = errors.New("rabbitmq connection is closed")
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"00-go-base-tpl-sv/internal/metrics"
+	"00-go-base-tpl-sv/internal/tracing"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/xid"
+)
+
+// Publisher publishes JSON events to the exchange of the connection over a
+// channel of its own, reopened after the broker closes it.
+type Publisher struct {
+	conn    *Conn
+	metrics *metrics.AMQP
+
+	mu sync.Mutex
+	ch *amqp.Channel
+}
+
+func NewPublisher(conn *Conn, metrics *metrics.AMQP) *Publisher {
+	return &Publisher{conn: conn, metrics: metrics}
+}
+
+// Publish sends event persistently with the trace context of ctx in its
+// headers.
+func (p *Publisher) Publish(ctx context.Context, routingKey string, event any) (err error) {
+	exchange := p.conn.config.Exchange
+
+	defer func() { p.metrics.Published(exchange, routingKey, err) }()
+
+	body, err := sonic.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", routingKey, err)
+	}
+
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    xid.New().String(),
+		Timestamp:    time.Now().UTC(),
+		Body:         body,
+	}
+
+	ctx, span := tracing.StartPublish(ctx, exchange, routingKey, &msg)
+	defer func() { tracing.Finish(span, err) }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ch == nil || p.ch.IsClosed() {
+		ch, err := p.conn.channel()
+		if err != nil {
+			return fmt.Errorf("open channel: %w", err)
+		}
+
+		p.ch = ch
+	}
+
+	if err := p.ch.PublishWithContext(ctx, exchange, routingKey, false, false, msg); err != nil {
+		return fmt.Errorf("publish %s: %w", routingKey, err)
+	}
+
+	return nil
+}
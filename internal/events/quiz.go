@@ -0,0 +1,80 @@
+package events
+
+import (
+	"00-go-base-tpl-sv/internal/quiz"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const RoutingSessionFinished = "quiz.session.finished"
+
+// SessionFinished is published once per completed or abandoned session.
+type SessionFinished struct {
+	SessionID  string    `json:"session_id"`
+	TelegramID int64     `json:"telegram_id"`
+	Mode       string    `json:"mode"`
+	Outcome    string    `json:"outcome"`
+	Score      int       `json:"score"`
+	MaxPoints  int       `json:"max_points"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// QuizEvents publishes quiz events. The session is saved by the time an
+// event is published, so failures are logged rather than returned.
+type QuizEvents struct {
+	publisher *Publisher
+	log       *zap.Logger
+}
+
+var _ quiz.Events = (*QuizEvents)(nil)
+
+func NewQuizEvents(publisher *Publisher, log *zap.Logger) *QuizEvents {
+	return &QuizEvents{publisher: publisher, log: log}
+}
+
+func (e *QuizEvents) SessionFinished(ctx context.Context, s quiz.Session) {
+	event := SessionFinished{
+		SessionID:  s.ID.String(),
+		TelegramID: s.TelegramID,
+		Mode:       s.Mode,
+		Outcome:    s.Outcome,
+		Score:      s.Score,
+		MaxPoints:  s.MaxPoints,
+	}
+
+	if s.FinishedAt != nil {
+		event.FinishedAt = *s.FinishedAt
+	}
+
+	if err := e.publisher.Publish(ctx, RoutingSessionFinished, event); err != nil {
+		e.log.Error("publish session finished", zap.Stringer("session_id", s.ID), zap.Error(err))
+	}
+}
+
+// LogSessionFinished logs finished sessions. It is the simplest consumer of
+// quiz events, others like leaderboards are built the same way.
+func LogSessionFinished(log *zap.Logger) Handler {
+	return func(_ context.Context, d amqp.Delivery) error {
+		var event SessionFinished
+		if err := sonic.Unmarshal(d.Body, &event); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", d.RoutingKey, err)
+		}
+
+		log.Info(
+			"quiz session finished",
+			zap.String("session_id", event.SessionID),
+			zap.Int64("telegram_id", event.TelegramID),
+			zap.String("mode", event.Mode),
+			zap.String("outcome", event.Outcome),
+			zap.Int("score", event.Score),
+			zap.Int("max_points", event.MaxPoints),
+		)
+
+		return nil
+	}
+}
@@ -0,0 +1,320 @@
+package frontend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// StaticPrefix is the URL path assets are served under.
+const StaticPrefix = "/static/"
+
+const (
+	staticDir  = "static"
+	hashLength = 10
+
+	cacheImmutable = "public, max-age=31536000, immutable"
+	cacheRevalid   = "no-cache"
+	cacheDev       = "no-store"
+)
+
+//go:embed *.html static
+var embedded embed.FS
+
+// Embedded returns the frontend files compiled into the binary.
+func Embedded() fs.FS {
+	return embedded
+}
+
+// Frontend renders page templates and serves static assets. Assets are
+// referenced from templates with {{ asset "app.js" }}, which yields a
+// content-hashed URL that is cached forever.
+type Frontend struct {
+	fsys fs.FS
+	dev  bool
+	site *site
+}
+
+type site struct {
+	templates *template.Template
+	assets    map[string]*asset // by hashed and plain name
+	urls      map[string]string // plain name to hashed URL
+}
+
+type asset struct {
+	hashed      bool
+	contentType string
+	etag        string
+	raw         []byte
+	gzip        []byte
+	brotli      []byte
+}
+
+// New reads templates and assets from fsys once. In dev mode fsys is read
+// on every request, so edits on disk show up without a rebuild, and nothing
+// is compressed or cached.
+func New(fsys fs.FS, dev bool) (*Frontend, error) {
+	s, err := build(fsys, !dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Frontend{fsys: fsys, dev: dev, site: s}, nil
+}
+
+// Render executes the named template into w.
+func (f *Frontend) Render(w io.Writer, name string, data interface{}) error {
+	s, err := f.current()
+	if err != nil {
+		return err
+	}
+
+	return s.templates.ExecuteTemplate(w, name, data)
+}
+
+// Assets serves files from the static dir, expecting StaticPrefix to be
+// stripped from the request path.
+func (f *Frontend) Assets() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := f.current()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		a, ok := s.assets[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		f.serve(w, r, a)
+	})
+}
+
+func (f *Frontend) current() (*site, error) {
+	if !f.dev {
+		return f.site, nil
+	}
+
+	return build(f.fsys, false)
+}
+
+func (f *Frontend) serve(w http.ResponseWriter, r *http.Request, a *asset) {
+	h := w.Header()
+
+	switch {
+	case f.dev:
+		h.Set("Cache-Control", cacheDev)
+	case a.hashed:
+		h.Set("Cache-Control", cacheImmutable)
+	default:
+		h.Set("Cache-Control", cacheRevalid)
+	}
+
+	h.Set("Content-Type", a.contentType)
+	h.Set("ETag", a.etag)
+	h.Set("Vary", "Accept-Encoding")
+
+	if noneMatch(r.Header.Values("If-None-Match"), a.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body := a.raw
+	accept := r.Header.Get("Accept-Encoding")
+
+	switch {
+	case a.brotli != nil && acceptsEncoding(accept, "br"):
+		h.Set("Content-Encoding", "br")
+		body = a.brotli
+	case a.gzip != nil && acceptsEncoding(accept, "gzip"):
+		h.Set("Content-Encoding", "gzip")
+		body = a.gzip
+	}
+
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = w.Write(body) // nolint client is gone if write fails
+}
+
+// noneMatch reports whether If-None-Match values list etag or "*". GET and
+// HEAD compare weakly, RFC 7232 section 3.2, so W/ prefixes are ignored.
+func noneMatch(values []string, etag string) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func build(fsys fs.FS, compress bool) (*site, error) {
+	s := &site{
+		assets: make(map[string]*asset),
+		urls:   make(map[string]string),
+	}
+
+	static, err := fs.Sub(fsys, staticDir)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", staticDir, err)
+	}
+
+	err = fs.WalkDir(static, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(static, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])[:hashLength]
+
+		a, err := newAsset(name, data, hash, compress)
+		if err != nil {
+			return fmt.Errorf("asset %s: %w", name, err)
+		}
+
+		hashedName := hashedPath(name, hash)
+		hashedAsset := *a
+		hashedAsset.hashed = true
+
+		s.assets[name] = a
+		s.assets[hashedName] = &hashedAsset
+		s.urls[name] = StaticPrefix + hashedName
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read assets: %w", err)
+	}
+
+	templates, err := template.New("").Funcs(template.FuncMap{"asset": s.assetURL}).ParseFS(fsys, "*.html")
+	if err != nil {
+		return nil, fmt.Errorf("parse templates: %w", err)
+	}
+
+	s.templates = templates
+
+	return s, nil
+}
+
+func (s *site) assetURL(name string) (string, error) {
+	u, ok := s.urls[name]
+	if !ok {
+		return "", fmt.Errorf("unknown asset %q", name)
+	}
+
+	return u, nil
+}
+
+func newAsset(name string, data []byte, hash string, compress bool) (*asset, error) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	a := &asset{
+		contentType: contentType,
+		etag:        `"` + hash + `"`,
+		raw:         data,
+	}
+
+	if !compress || !compressible(contentType) {
+		return a, nil
+	}
+
+	var buf bytes.Buffer
+
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() < len(data) {
+		a.gzip = append([]byte(nil), buf.Bytes()...)
+	}
+
+	buf.Reset()
+
+	br := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+
+	if _, err := br.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := br.Close(); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() < len(data) {
+		a.brotli = append([]byte(nil), buf.Bytes()...)
+	}
+
+	return a, nil
+}
+
+// hashedPath turns "img/logo.png" into "img/logo.<hash>.png".
+func hashedPath(name, hash string) string {
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/javascript", mediaType == "application/json", mediaType == "image/svg+xml":
+		return true
+	default:
+		return false
+	}
+}
+
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(name) != encoding {
+			continue
+		}
+
+		return strings.ReplaceAll(params, " ", "") != "q=0"
+	}
+
+	return false
+}
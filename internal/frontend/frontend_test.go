@@ -0,0 +1,31 @@
+package frontend
+
+import "testing"
+
+func TestNoneMatch(t *testing.T) {
+	const etag = `"abc123"`
+
+	tests := []struct {
+		name   string
+		values []string
+		want   bool
+	}{
+		{name: "no header", want: false},
+		{name: "same tag", values: []string{`"abc123"`}, want: true},
+		{name: "weak tag", values: []string{`W/"abc123"`}, want: true},
+		{name: "in a list", values: []string{`"x", "abc123" ,"y"`}, want: true},
+		{name: "in a second header", values: []string{`"x"`, `"abc123"`}, want: true},
+		{name: "any", values: []string{`*`}, want: true},
+		{name: "other tag", values: []string{`"abc"`}, want: false},
+		{name: "longer tag containing ours", values: []string{`"abc123"x"`, `"xabc123"`}, want: false},
+		{name: "unquoted", values: []string{`abc123`}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noneMatch(tt.values, etag); got != tt.want {
+				t.Errorf("noneMatch(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,573 @@
+const webApp = window.Telegram.WebApp
+
+// Delay before the next question, so the player sees whether they were right.
+const revealDelayMS = 1200
+
+// Suggested questions are single choice with this many options.
+const suggestOptions = 4
+
+let bootstrap = null
+let mainButtonAction = null
+let timer = null
+
+// The first load has no bootstrap payload: post the signed initData back so
+// the server can verify the user and render the page for them.
+function reloadWithInitData() {
+    const initData = webApp.initData
+    if (!initData) {
+        return
+    }
+
+    const form = document.createElement("form")
+    form.method = "POST"
+    form.action = "/"
+
+    const input = document.createElement("input")
+    input.type = "hidden"
+    input.name = "init_data"
+    input.value = initData
+
+    form.appendChild(input)
+    document.body.appendChild(form)
+    form.submit()
+}
+
+async function api(method, path, body) {
+    const headers = {
+        "Authorization": "tma " + webApp.initData,
+        "X-Nonce": bootstrap.nonce,
+    }
+
+    if (body !== undefined) {
+        headers["Content-Type"] = "application/json"
+    }
+
+    const response = await fetch(path, {method, headers, body: body === undefined ? undefined : JSON.stringify(body)})
+    const data = await response.json()
+
+    if (!response.ok) {
+        const err = new Error(data.message || response.statusText)
+        err.code = data.code
+        err.title = data.title
+        throw err
+    }
+
+    return data
+}
+
+// t returns the message of key in the user's language with {name}
+// placeholders replaced from params.
+function t(key, params) {
+    const text = (bootstrap && bootstrap.messages && bootstrap.messages[key]) || key
+
+    return text.replace(/{(\w+)}/g, function (match, name) {
+        return params && name in params ? params[name] : match
+    })
+}
+
+function translatePage() {
+    document.querySelectorAll("[data-i18n]").forEach(function (element) {
+        element.innerText = t(element.dataset.i18n)
+    })
+
+    document.querySelectorAll("[data-i18n-placeholder]").forEach(function (element) {
+        element.placeholder = t(element.dataset.i18nPlaceholder)
+    })
+
+    const greeting = document.getElementById("greeting")
+    if (greeting) {
+        const profile = bootstrap.profile
+        greeting.innerText = profile.username
+            ? t("home.greeting_username", {name: profile.first_name, username: profile.username})
+            : t("home.greeting", {name: profile.first_name})
+    }
+}
+
+function show(id) {
+    for (const screen of document.querySelectorAll(".screen")) {
+        screen.classList.toggle("hidden", screen.id !== id)
+    }
+}
+
+function setMainButton(text, action) {
+    if (mainButtonAction) {
+        webApp.MainButton.offClick(mainButtonAction)
+    }
+
+    mainButtonAction = action
+    webApp.MainButton.setText(text)
+    webApp.MainButton.onClick(action)
+    webApp.MainButton.show()
+}
+
+function hideMainButton() {
+    if (mainButtonAction) {
+        webApp.MainButton.offClick(mainButtonAction)
+        mainButtonAction = null
+    }
+
+    webApp.MainButton.hide()
+}
+
+function showError(err) {
+    stopTimer()
+    document.getElementById("error_text").innerText = err.title || err.message
+    show("error")
+    setMainButton(t("button.play_again"), play)
+}
+
+function renderAnswered(id, session) {
+    const container = document.getElementById(id)
+    container.replaceChildren()
+
+    for (let i = 0; i < session.total; i++) {
+        const dot = document.createElement("span")
+        const answer = session.answers[i]
+
+        if (answer) {
+            dot.className = answer.correct ? "correct" : "incorrect"
+        }
+
+        container.appendChild(dot)
+    }
+}
+
+function stopTimer() {
+    if (timer) {
+        clearInterval(timer)
+        timer = null
+    }
+}
+
+// startTimer counts down from the server's remaining time, so a reload
+// doesn't give the player a fresh timer.
+function startTimer(session, onExpire) {
+    stopTimer()
+
+    const deadline = Date.now() + session.remaining_ms
+    const label = document.getElementById("timer")
+    const fill = document.getElementById("timer_fill")
+
+    const tick = function () {
+        const remaining = Math.max(0, deadline - Date.now())
+
+        label.innerText = Math.ceil(remaining / 1000) + "s"
+        fill.style.width = (100 * remaining / session.time_limit_ms) + "%"
+
+        if (remaining === 0) {
+            stopTimer()
+            onExpire()
+        }
+    }
+
+    tick()
+    timer = setInterval(tick, 200)
+}
+
+function renderMedia(media) {
+    const container = document.getElementById("media")
+    container.replaceChildren()
+
+    if (!media) {
+        return
+    }
+
+    const tags = {image: "img", video: "video", audio: "audio"}
+    const element = document.createElement(tags[media.kind])
+    element.src = media.url
+
+    if (media.kind === "image") {
+        element.alt = media.alt || ""
+    } else {
+        element.controls = true
+    }
+
+    container.appendChild(element)
+}
+
+function optionButton(option, onClick) {
+    const button = document.createElement("button")
+    button.className = "answer-button"
+    button.dataset.id = option.id
+    button.innerText = option.text
+    button.addEventListener("click", onClick)
+
+    return button
+}
+
+// renderOptions submits single-choice and true/false answers on click, other
+// types are submitted with the MainButton.
+function renderOptions(session) {
+    const question = session.question
+    const options = document.getElementById("options")
+    options.replaceChildren()
+
+    switch (question.type) {
+        case "multiple_choice":
+            question.options.forEach(function (option) {
+                options.appendChild(optionButton(option, function () {
+                    this.classList.toggle("selected")
+                }))
+            })
+
+            setMainButton(t("button.submit"), function () {
+                const ids = Array.from(options.querySelectorAll(".selected")).map(function (button) {
+                    return button.dataset.id
+                })
+
+                submitAnswer(session, {option_ids: ids})
+            })
+            break
+        case "numeric": {
+            const input = document.createElement("input")
+            input.type = "number"
+            input.step = "any"
+            input.className = "numeric-input"
+            options.appendChild(input)
+
+            if (question.unit) {
+                const unit = document.createElement("span")
+                unit.className = "unit"
+                unit.innerText = question.unit
+                options.appendChild(unit)
+            }
+
+            setMainButton(t("button.submit"), function () {
+                if (input.value === "") {
+                    return
+                }
+
+                submitAnswer(session, {number: Number(input.value)})
+            })
+            break
+        }
+        default:
+            hideMainButton()
+
+            question.options.forEach(function (option) {
+                options.appendChild(optionButton(option, function () {
+                    submitAnswer(session, {option_ids: [option.id]})
+                }))
+            })
+    }
+}
+
+function renderQuestion(session) {
+    const question = session.question
+
+    document.getElementById("progress").innerText = t("quiz.progress", {index: question.index + 1, total: session.total})
+    document.getElementById("question_text").innerText = question.text
+    renderAnswered("answered", session)
+    renderMedia(question.media)
+    renderOptions(session)
+    document.getElementById("points").classList.add("hidden")
+
+    show("question")
+    startTimer(session, function () {
+        submitAnswer(session, {})
+    })
+}
+
+function renderResults(session) {
+    const correct = session.answers.filter(function (answer) {
+        return answer.correct
+    }).length
+
+    document.getElementById("score").innerText = session.score + " / " + session.max_score
+    document.getElementById("correct_count").innerText = t("results.correct", {correct: correct, total: session.total})
+    renderAnswered("results_answered", session)
+
+    show("results")
+    setMainButton(t("button.play_again"), play)
+}
+
+function next(session) {
+    if (session.status === "finished") {
+        renderResults(session)
+    } else {
+        renderQuestion(session)
+    }
+}
+
+function revealAnswer(body, result) {
+    const options = document.getElementById("options")
+
+    if (result.correct_number !== undefined) {
+        const reveal = document.createElement("p")
+        reveal.className = result.correct ? "reveal correct" : "reveal incorrect"
+        reveal.innerText = t("quiz.answer", {value: result.correct_number})
+        options.appendChild(reveal)
+
+        return
+    }
+
+    const correct = new Set(result.correct_option_ids)
+    const picked = new Set(body.option_ids || [])
+
+    options.querySelectorAll(".answer-button").forEach(function (button) {
+        if (correct.has(button.dataset.id)) {
+            button.classList.add("correct")
+        } else if (picked.has(button.dataset.id)) {
+            button.classList.add("incorrect")
+        }
+    })
+}
+
+// renderPoints shows what the answer scored, with the bonuses that made it.
+function renderPoints(result) {
+    const parts = [t("quiz.points", {points: result.points})]
+
+    if (result.breakdown.speed > 0) {
+        parts.push(t("quiz.speed_bonus", {points: result.breakdown.speed}))
+    }
+
+    if (result.breakdown.streak > 0) {
+        parts.push(t("quiz.streak_bonus", {points: result.breakdown.streak, streak: result.session.streak}))
+    }
+
+    const points = document.getElementById("points")
+    points.innerText = parts.join(" · ")
+    points.classList.toggle("hidden", false)
+}
+
+// submitAnswer sends body with option_ids or number, an empty body when the
+// timer ran out.
+async function submitAnswer(session, body) {
+    stopTimer()
+    hideMainButton()
+
+    document.querySelectorAll("#options button, #options input").forEach(function (element) {
+        element.disabled = true
+    })
+
+    let result
+
+    try {
+        result = await api("POST", "/quiz/sessions/" + session.id + "/answers", Object.assign({question: session.question.index}, body))
+    } catch (err) {
+        // the answer may have been accepted already, continue from the server state
+        if (err.code === "question_mismatch") {
+            return resume()
+        }
+
+        return showError(err)
+    }
+
+    revealAnswer(body, result)
+    renderPoints(result)
+    webApp.HapticFeedback.notificationOccurred(result.correct ? "success" : result.credit > 0 ? "warning" : "error")
+
+    setTimeout(function () {
+        next(result.session)
+    }, revealDelayMS)
+}
+
+async function resume() {
+    try {
+        const data = await api("GET", "/quiz/sessions/current")
+        next(data.session)
+    } catch (err) {
+        if (err.code === "quiz_session_not_found") {
+            return play()
+        }
+
+        showError(err)
+    }
+}
+
+async function loadCategories() {
+    const select = document.getElementById("deck_category")
+
+    try {
+        const data = await api("GET", "/quiz/categories")
+
+        for (const category of data.categories) {
+            const option = document.createElement("option")
+            option.value = category.name
+            option.innerText = category.name + " (" + category.questions + ")"
+            select.appendChild(option)
+        }
+    } catch (err) {
+        // "Any" still works, the list is a nicety
+        console.warn("load categories", err)
+    }
+}
+
+function deck() {
+    return {
+        category: document.getElementById("deck_category").value,
+        difficulty: Number(document.getElementById("deck_difficulty").value),
+    }
+}
+
+async function play() {
+    hideMainButton()
+
+    try {
+        const data = await api("POST", "/quiz/sessions", deck())
+        next(data.session)
+    } catch (err) {
+        showError(err)
+    }
+}
+
+function showHome() {
+    show("home")
+    setMainButton(t("button.play"), play)
+}
+
+function renderSuggestForm() {
+    const container = document.getElementById("suggest_options")
+    container.replaceChildren()
+
+    for (let i = 0; i < suggestOptions; i++) {
+        const row = document.createElement("label")
+        row.className = "suggest-option"
+
+        const correct = document.createElement("input")
+        correct.type = "radio"
+        correct.name = "suggest_correct"
+        correct.checked = i === 0
+
+        const text = document.createElement("input")
+        text.maxLength = 256
+        text.placeholder = t("suggest.answer", {index: i + 1})
+
+        row.append(correct, text)
+        container.appendChild(row)
+    }
+
+    document.getElementById("suggest_text").value = ""
+    show("suggest")
+    setMainButton(t("button.submit"), submitSuggestion)
+}
+
+function suggestion() {
+    const options = Array.from(document.querySelectorAll(".suggest-option")).map(function (row, i) {
+        const inputs = row.querySelectorAll("input")
+
+        return {id: String.fromCharCode(97 + i), text: inputs[1].value.trim(), correct: inputs[0].checked}
+    })
+
+    return {
+        type: "single_choice",
+        text: document.getElementById("suggest_text").value.trim(),
+        options: options,
+        category: document.getElementById("suggest_category").value.trim(),
+        tags: [],
+        difficulty: Number(document.getElementById("suggest_difficulty").value),
+        language: bootstrap.locale,
+    }
+}
+
+async function submitSuggestion() {
+    try {
+        await api("POST", "/quiz/community/questions", suggestion())
+    } catch (err) {
+        return webApp.showAlert(err.title || err.message)
+    }
+
+    webApp.showAlert(t("suggest.thanks"), showHome)
+}
+
+function reviewCard(item) {
+    const card = document.createElement("div")
+    card.className = "review-card"
+
+    const text = document.createElement("h3")
+    text.innerText = item.question.text
+    card.appendChild(text)
+
+    const options = document.createElement("ul")
+    for (const option of item.question.options || []) {
+        const li = document.createElement("li")
+        li.innerText = option.text
+        li.classList.toggle("correct", option.correct)
+        options.appendChild(li)
+    }
+    card.appendChild(options)
+
+    const score = document.createElement("p")
+    score.className = "hint"
+    const renderScore = function (item) {
+        score.innerText = "+" + item.upvotes + " / -" + item.downvotes
+    }
+    renderScore(item)
+    card.appendChild(score)
+
+    const actions = document.createElement("div")
+    actions.className = "review-actions"
+
+    const act = function (label, path, body) {
+        const button = document.createElement("button")
+        button.className = "link-button"
+        button.innerText = label
+        button.addEventListener("click", async function () {
+            try {
+                const data = await api("POST", "/quiz/community/questions/" + item.id + path, body)
+                renderScore(data.question)
+                actions.querySelectorAll("button").forEach(function (b) {
+                    b.disabled = true
+                })
+            } catch (err) {
+                webApp.showAlert(err.title || err.message)
+            }
+        })
+        actions.appendChild(button)
+    }
+
+    act("👍", "/votes", {vote: "up"})
+    act("👎", "/votes", {vote: "down"})
+    act(t("review.report"), "/reports", {reason: ""})
+
+    card.appendChild(actions)
+
+    return card
+}
+
+async function openReview() {
+    const list = document.getElementById("review_list")
+    list.replaceChildren()
+    show("review")
+    setMainButton(t("button.back"), showHome)
+
+    try {
+        const data = await api("GET", "/quiz/community/questions")
+
+        if (data.questions.length === 0) {
+            const empty = document.createElement("p")
+            empty.innerText = t("review.empty")
+            list.appendChild(empty)
+        }
+
+        for (const item of data.questions) {
+            list.appendChild(reviewCard(item))
+        }
+    } catch (err) {
+        showError(err)
+    }
+}
+
+function start(b) {
+    bootstrap = b
+    webApp.ready()
+    translatePage()
+
+    if (b.session) {
+        next(b.session)
+    } else {
+        showHome()
+    }
+
+    document.getElementById("open_suggest").addEventListener("click", renderSuggestForm)
+    document.getElementById("open_review").addEventListener("click", openReview)
+    document.getElementById("suggest_cancel").addEventListener("click", showHome)
+
+    loadCategories()
+}
+
+if (window.BOOTSTRAP) {
+    start(window.BOOTSTRAP)
+} else {
+    reloadWithInitData()
+}
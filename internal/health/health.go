@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Health backs the Kubernetes probes. The app flips started once booted and
+// turns ready off at the beginning of shutdown to let load balancers drain.
+type Health struct {
+	started atomic.Bool
+	ready   atomic.Bool
+
+	checkTimeout time.Duration
+	checks       []namedCheck
+}
+
+func New(checkTimeout time.Duration) *Health {
+	return &Health{checkTimeout: checkTimeout}
+}
+
+// AddReadinessCheck registers a dependency, readiness fails while any of the
+// checks fails.
+func (h *Health) AddReadinessCheck(name string, check CheckFunc) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+func (h *Health) SetStarted() {
+	h.started.Store(true)
+}
+
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *Health) Liveness(w http.ResponseWriter, _ *http.Request) {
+	write(w, http.StatusOK, response{Status: statusOK})
+}
+
+func (h *Health) Startup(w http.ResponseWriter, _ *http.Request) {
+	if !h.started.Load() {
+		write(w, http.StatusServiceUnavailable, response{Status: "starting"})
+		return
+	}
+
+	write(w, http.StatusOK, response{Status: statusOK})
+}
+
+func (h *Health) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.started.Load() {
+		write(w, http.StatusServiceUnavailable, response{Status: "starting"})
+		return
+	}
+
+	if !h.ready.Load() {
+		write(w, http.StatusServiceUnavailable, response{Status: "draining"})
+		return
+	}
+
+	resp := h.runChecks(r.Context())
+
+	status := http.StatusOK
+	if resp.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	write(w, status, resp)
+}
+
+func (h *Health) runChecks(ctx context.Context) response {
+	ctx, cancel := context.WithTimeout(ctx, h.checkTimeout)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		resp = response{Status: statusOK, Checks: make(map[string]string, len(h.checks))}
+	)
+
+	for _, c := range h.checks {
+		wg.Add(1)
+
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			result := statusOK
+			if err := c.check(ctx); err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			resp.Checks[c.name] = result
+			if result != statusOK {
+				resp.Status = statusFail
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return resp
+}
+
+func write(w http.ResponseWriter, status int, resp response) {
+	data, err := sonic.ConfigFastest.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data) // nolint probe clients don't care about partial writes
+}
@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+const localesDir = "locales"
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Catalog holds user-facing messages by locale, one flat JSON object of key
+// to text per locale in locales/<locale>.json. The default locale must have
+// every message, other locales may miss some.
+type Catalog struct {
+	defaultLocale string
+	fallbacks     map[string]string
+	messages      map[string]map[string]string
+}
+
+// NewCatalog reads the embedded messages. fallbacks maps a locale to the one
+// tried next, before the default locale.
+func NewCatalog(defaultLocale string, fallbacks map[string]string) (*Catalog, error) {
+	c := &Catalog{
+		defaultLocale: defaultLocale,
+		fallbacks:     fallbacks,
+		messages:      make(map[string]map[string]string),
+	}
+
+	files, err := fs.Glob(embedded, localesDir+"/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range files {
+		data, err := fs.ReadFile(embedded, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var messages map[string]string
+		if err := sonic.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+
+		c.messages[strings.TrimSuffix(path.Base(name), ".json")] = messages
+	}
+
+	defaults, ok := c.messages[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no messages for default locale %q", defaultLocale)
+	}
+
+	// a key missing in the default locale is a typo
+	for locale, messages := range c.messages {
+		for key := range messages {
+			if _, ok := defaults[key]; !ok {
+				return nil, fmt.Errorf("locale %q has unknown message %q", locale, key)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Normalize keeps the primary subtag of an IETF tag, "pt-BR" becomes "pt".
+func Normalize(code string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(code, "-", 2)[0]))
+}
+
+// Localizer returns the localizer for a Telegram language_code or an IETF
+// tag.
+func (c *Catalog) Localizer(languageCode string) Localizer {
+	var locales []string
+
+	seen := make(map[string]bool)
+	for locale := Normalize(languageCode); locale != "" && !seen[locale]; locale = c.fallbacks[locale] {
+		seen[locale] = true
+		locales = append(locales, locale)
+	}
+
+	if !seen[c.defaultLocale] {
+		locales = append(locales, c.defaultLocale)
+	}
+
+	return Localizer{catalog: c, locales: locales}
+}
+
+// Localizer looks messages up in the locales of one user. The zero value
+// has no messages.
+type Localizer struct {
+	catalog *Catalog
+	locales []string
+}
+
+// Locales lists the user's locale, its fallbacks and the default locale, in
+// that order. Locales without messages are kept, questions may be translated
+// into them.
+func (l Localizer) Locales() []string {
+	return l.locales
+}
+
+// Locale is the user's locale, or the default one when the user has no
+// language.
+func (l Localizer) Locale() string {
+	if len(l.locales) == 0 {
+		return ""
+	}
+
+	return l.locales[0]
+}
+
+// Message returns the text of key in the first locale having it, an empty
+// string for unknown keys.
+func (l Localizer) Message(key string) string {
+	if l.catalog == nil {
+		return ""
+	}
+
+	for _, locale := range l.locales {
+		if text, ok := l.catalog.messages[locale][key]; ok {
+			return text
+		}
+	}
+
+	return ""
+}
+
+// Messages returns every message resolved like Message, for clients which
+// render texts themselves.
+func (l Localizer) Messages() map[string]string {
+	if l.catalog == nil {
+		return nil
+	}
+
+	defaults := l.catalog.messages[l.catalog.defaultLocale]
+	messages := make(map[string]string, len(defaults))
+
+	for key := range defaults {
+		messages[key] = l.Message(key)
+	}
+
+	return messages
+}
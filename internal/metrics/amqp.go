@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type AMQP struct {
+	published *prometheus.CounterVec
+	consumed  *prometheus.HistogramVec
+}
+
+func NewAMQP(reg prometheus.Registerer) *AMQP {
+	m := &AMQP{
+		published: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "amqp_published_total",
+				Help: "Count of messages published to RabbitMQ.",
+			},
+			[]string{"exchange", "routing_key", "status"},
+		),
+		consumed: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "amqp_consume_duration_seconds",
+				Help:    "Duration of handling messages consumed from RabbitMQ.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"queue", "status"},
+		),
+	}
+
+	reg.MustRegister(m.published, m.consumed)
+
+	return m
+}
+
+func (m *AMQP) Published(exchange, routingKey string, err error) {
+	m.published.WithLabelValues(exchange, routingKey, errStatus(err)).Inc()
+}
+
+func (m *AMQP) Consumed(queue string, err error, d time.Duration) {
+	m.consumed.WithLabelValues(queue, errStatus(err)).Observe(d.Seconds())
+}
+
+func errStatus(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Count of handled HTTP requests.",
+			},
+			[]string{"handler", "method", "status"},
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "Duration of handled HTTP requests.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"handler", "method", "status"},
+		),
+		inFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "Count of HTTP requests being handled now.",
+			},
+			[]string{"handler"},
+		),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+
+	return m
+}
+
+func (m *HTTP) Started(handler string) {
+	m.inFlight.WithLabelValues(handler).Inc()
+}
+
+func (m *HTTP) Finished(handler, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+
+	m.inFlight.WithLabelValues(handler).Dec()
+	m.requests.WithLabelValues(handler, method, code).Inc()
+	m.duration.WithLabelValues(handler, method, code).Observe(duration.Seconds())
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type Mongo struct {
+	duration *prometheus.HistogramVec
+	pool     *prometheus.GaugeVec
+}
+
+func NewMongo(reg prometheus.Registerer) *Mongo {
+	m := &Mongo{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mongo_command_duration_seconds",
+				Help:    "Duration of Mongo commands.",
+				Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+			},
+			[]string{"command", "status"},
+		),
+		pool: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongo_pool_connections",
+				Help: "Count of Mongo pool connections by state.",
+			},
+			[]string{"state"},
+		),
+	}
+
+	reg.MustRegister(m.duration, m.pool)
+
+	return m
+}
+
+func (m *Mongo) CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			m.duration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			m.duration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+func (m *Mongo) PoolMonitor() *event.PoolMonitor {
+	open := m.pool.WithLabelValues("open")
+	inUse := m.pool.WithLabelValues("in_use")
+
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				open.Inc()
+			case event.ConnectionClosed:
+				open.Dec()
+			case event.GetSucceeded:
+				inUse.Inc()
+			case event.ConnectionReturned:
+				inUse.Dec()
+			}
+		},
+	}
+}
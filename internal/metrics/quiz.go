@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const activeUserWindow = 5 * time.Minute
+
+// Quiz holds gameplay metrics of the quiz service and the WebApp.
+type Quiz struct {
+	sessionsStarted  *prometheus.CounterVec
+	sessionsFinished *prometheus.CounterVec
+	answers          *prometheus.CounterVec
+
+	activeMu     sync.Mutex
+	activeUsers  map[int64]time.Time
+	activePruned time.Time
+}
+
+func NewQuiz(reg prometheus.Registerer) *Quiz {
+	m := &Quiz{
+		sessionsStarted: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "quiz_sessions_started_total",
+				Help: "Count of started quiz sessions.",
+			},
+			[]string{"mode"},
+		),
+		sessionsFinished: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "quiz_sessions_finished_total",
+				Help: "Count of finished quiz sessions by outcome.",
+			},
+			[]string{"mode", "outcome"},
+		),
+		answers: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "quiz_answers_total",
+				Help: "Count of submitted answers by question type and difficulty.",
+			},
+			[]string{"type", "difficulty", "result"},
+		),
+		activeUsers: make(map[int64]time.Time),
+	}
+
+	active := prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "quiz_webapp_active_users",
+			Help: "Count of distinct WebApp users seen during the last 5 minutes.",
+		},
+		func() float64 {
+			return float64(m.countActiveUsers(time.Now()))
+		},
+	)
+
+	reg.MustRegister(
+		m.sessionsStarted,
+		m.sessionsFinished,
+		m.answers,
+		active,
+	)
+
+	return m
+}
+
+func (m *Quiz) SessionStarted(mode string) {
+	m.sessionsStarted.WithLabelValues(mode).Inc()
+}
+
+func (m *Quiz) SessionFinished(mode, outcome string) {
+	m.sessionsFinished.WithLabelValues(mode, outcome).Inc()
+}
+
+// Answer is labeled by bounded question traits only. Question IDs and
+// categories grow with the bank, per-question stats belong in Mongo.
+func (m *Quiz) Answer(questionType string, difficulty int, correct bool) {
+	result := "incorrect"
+	if correct {
+		result = "correct"
+	}
+
+	m.answers.WithLabelValues(questionType, strconv.Itoa(difficulty), result).Inc()
+}
+
+// UserSeen forgets stale users once per window, so the set stays bounded
+// by recent users even when nothing scrapes.
+func (m *Quiz) UserSeen(telegramID int64) {
+	now := time.Now()
+
+	m.activeMu.Lock()
+	defer m.activeMu.Unlock()
+
+	m.activeUsers[telegramID] = now
+
+	if now.Sub(m.activePruned) > activeUserWindow {
+		m.pruneActiveUsers(now)
+	}
+}
+
+// countActiveUsers is called on every scrape.
+func (m *Quiz) countActiveUsers(now time.Time) int {
+	m.activeMu.Lock()
+	defer m.activeMu.Unlock()
+
+	m.pruneActiveUsers(now)
+
+	return len(m.activeUsers)
+}
+
+func (m *Quiz) pruneActiveUsers(now time.Time) {
+	for id, seen := range m.activeUsers {
+		if now.Sub(seen) > activeUserWindow {
+			delete(m.activeUsers, id)
+		}
+	}
+
+	m.activePruned = now
+}
@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewRegistry returns a registry with Go runtime and process collectors.
+// Everything registered through it is labeled with the service name.
+func NewRegistry(serviceName string) (*prometheus.Registry, prometheus.Registerer) {
+	reg := prometheus.NewRegistry()
+
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return reg, prometheus.WrapRegistererWith(prometheus.Labels{"service": serviceName}, reg)
+}
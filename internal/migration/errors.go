@@ -0,0 +1,11 @@
+package migration
+
+import (
+	"errors"
+)
+
+var (
+	ErrLocked           = errors.New("migrations are locked by another process")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrPending          = errors.New("migrations are pending, run setup or migrate first")
+)
@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration is a single schema change. Versions are ordered globally across
+// every package, so use the date of writing, e.g. 2023101901. Up must be
+// idempotent: a pod may die after Up succeeded but before it was recorded.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+type Status struct {
+	Version   uint64     `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+type record struct {
+	Version   uint64        `bson:"_id"`
+	Name      string        `bson:"name"`
+	AppliedAt time.Time     `bson:"applied_at"`
+	Duration  time.Duration `bson:"duration"`
+}
+
+func CreateIndex(collection string, model mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, model)
+		if err != nil {
+			return fmt.Errorf("create index on %s: %w", collection, err)
+		}
+
+		return nil
+	}
+}
+
+func RenameField(collection, from, to string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(
+			ctx,
+			bson.M{from: bson.M{"$exists": true}},
+			bson.M{"$rename": bson.M{from: to}},
+		)
+		if err != nil {
+			return fmt.Errorf("rename %s.%s to %s: %w", collection, from, to, err)
+		}
+
+		return nil
+	}
+}
+
+// Backfill sets value to field on every document which has no such field yet.
+func Backfill(collection, field string, value interface{}) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(
+			ctx,
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{field: value}},
+		)
+		if err != nil {
+			return fmt.Errorf("backfill %s.%s: %w", collection, field, err)
+		}
+
+		return nil
+	}
+}
+
+// UpdateMany applies update, which may be an aggregation pipeline, to every
+// document matching filter. The filter must exclude already migrated ones.
+func UpdateMany(collection string, filter, update interface{}) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", collection, err)
+		}
+
+		return nil
+	}
+}
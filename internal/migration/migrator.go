@@ -0,0 +1,312 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	lockID              = "migrations"
+	lockTTL             = 5 * time.Minute
+	lockRefreshInterval = time.Minute
+	lockRetryWait       = time.Second
+)
+
+type Migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	locks      *mongo.Collection
+	migrations []Migration
+	owner      string
+}
+
+func NewMigrator(db *mongo.Database, collection string, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, sorted[i].Version)
+		}
+	}
+
+	hostname, _ := os.Hostname() // nolint empty hostname still leaves a unique owner
+
+	return &Migrator{
+		db:         db,
+		collection: db.Collection(collection),
+		locks:      db.Collection(collection + "_lock"),
+		migrations: sorted,
+		owner:      hostname + "/" + xid.New().String(),
+	}, nil
+}
+
+// Check fails with ErrPending while any migration is pending, so apps refuse
+// to start against a schema they don't know. Only setup and migrate apply
+// migrations.
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	versions := make([]string, 0, len(pending))
+	for _, st := range pending {
+		versions = append(versions, strconv.FormatUint(st.Version, 10))
+	}
+
+	return fmt.Errorf("%w: %s", ErrPending, strings.Join(versions, ", "))
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+
+		if r, ok := applied[mg.Version]; ok {
+			appliedAt := r.AppliedAt
+			st.AppliedAt = &appliedAt
+			delete(applied, mg.Version)
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	for _, r := range applied {
+		appliedAt := r.AppliedAt
+		statuses = append(statuses, Status{Version: r.Version, Name: r.Name, AppliedAt: &appliedAt})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Up applies pending migrations in version order and returns them. With
+// dryRun nothing is locked or changed, only the pending list is returned.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Status, error) {
+	if dryRun {
+		return m.pending(ctx)
+	}
+
+	if err := m.acquireLock(ctx); err != nil {
+		return nil, err
+	}
+
+	defer m.releaseLock(context.Background()) // nolint lock expires anyway
+
+	ctx, stop := m.holdLock(ctx)
+
+	done, err := m.apply(ctx)
+	if lost := stop(); lost != nil {
+		return done, lost
+	}
+
+	return done, err
+}
+
+func (m *Migrator) apply(ctx context.Context) ([]Status, error) {
+	pending, err := m.pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Status, 0, len(pending))
+
+	for _, st := range pending {
+		mg := m.find(st.Version)
+		started := time.Now()
+
+		if err := mg.Up(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+
+		r := record{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			AppliedAt: time.Now().UTC(),
+			Duration:  time.Since(started),
+		}
+
+		if _, err := m.collection.InsertOne(ctx, r); err != nil {
+			return done, fmt.Errorf("record migration %d: %w", mg.Version, err)
+		}
+
+		st.AppliedAt = &r.AppliedAt
+		done = append(done, st)
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) pending(ctx context.Context) ([]Status, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Status, 0, len(statuses))
+
+	for _, st := range statuses {
+		if !st.Applied() && m.find(st.Version) != nil {
+			pending = append(pending, st)
+		}
+	}
+
+	return pending, nil
+}
+
+func (m *Migrator) find(version uint64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint64]record, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("find applied migrations: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	applied := make(map[uint64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// acquireLock waits until the lock is free or expired and takes it. A held
+// lock doesn't match the filter, so the upsert fails with a duplicate key.
+func (m *Migrator) acquireLock(ctx context.Context) error {
+	for {
+		err := m.tryLock(ctx)
+		if !errors.Is(err, ErrLocked) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrLocked, ctx.Err())
+		case <-time.After(lockRetryWait):
+		}
+	}
+}
+
+func (m *Migrator) tryLock(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	err := m.locks.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"_id": lockID,
+			"$or": bson.A{
+				bson.M{"expires_at": bson.M{"$lt": now}},
+				bson.M{"owner": m.owner},
+			},
+		},
+		bson.M{"$set": bson.M{"owner": m.owner, "expires_at": now.Add(lockTTL)}},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err()
+
+	switch {
+	case err == nil, errors.Is(err, mongo.ErrNoDocuments):
+		return nil
+	case mongo.IsDuplicateKeyError(err):
+		return ErrLocked
+	default:
+		return fmt.Errorf("acquire migrations lock: %w", err)
+	}
+}
+
+// holdLock refreshes the lock until stop is called, as a single migration may
+// run longer than lockTTL. Losing the lock cancels the returned context and
+// stop returns why it was lost.
+func (m *Migrator) holdLock(ctx context.Context) (context.Context, func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		lost     error
+		stopping = make(chan struct{})
+		finished = make(chan struct{})
+	)
+
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(lockRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopping:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := m.tryLock(ctx); err != nil && ctx.Err() == nil {
+				lost = fmt.Errorf("refresh migrations lock: %w", err)
+				cancel()
+
+				return
+			}
+		}
+	}()
+
+	return ctx, func() error {
+		close(stopping)
+		<-finished
+		cancel()
+
+		return lost
+	}
+}
+
+func (m *Migrator) releaseLock(ctx context.Context) error {
+	_, err := m.locks.DeleteOne(ctx, bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		return fmt.Errorf("release migrations lock: %w", err)
+	}
+
+	return nil
+}
@@ -10,4 +10,5 @@ var (
 	ErrIDMismatch      = errors.New("id mismatch")
 	ErrVersionMismatch = errors.New("version mismatch")
 	ErrEmptyRequest    = errors.New("request is empty")
+	ErrSkipped         = errors.New("player skipped after previous failure")
 )
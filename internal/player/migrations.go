@@ -0,0 +1,30 @@
+package player
+
+import (
+	"00-go-base-tpl-sv/internal/migration"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func Migrations(collection string) []migration.Migration {
+	return []migration.Migration{
+		{
+			Version: 2023101901,
+			Name:    "player_email_unique_index",
+			Up: migration.CreateIndex(collection, mongo.IndexModel{
+				Keys:    bson.M{"email": 1},
+				Options: options.Index().SetUnique(true).SetName("email_idx"),
+			}),
+		},
+		{
+			Version: 2023101902,
+			Name:    "player_name_index",
+			Up: migration.CreateIndex(collection, mongo.IndexModel{
+				Keys:    bson.M{"name": 1},
+				Options: options.Index().SetName("name_idx"),
+			}),
+		},
+	}
+}
@@ -42,3 +42,22 @@ type FilterRequest struct {
 	Name  string `json:"name,omitempty"`
 	Email string `json:"email,omitempty"`
 }
+
+type Patch struct {
+	Email *string
+	Name  *string
+}
+
+func (p Patch) Empty() bool {
+	return p.Email == nil && p.Name == nil
+}
+
+type NewPlayer struct {
+	Email string
+	Name  string
+}
+
+type BatchResult struct {
+	Player Player
+	Err    error
+}
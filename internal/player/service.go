@@ -10,13 +10,17 @@ import (
 
 type Service interface {
 	Create(ctx context.Context, email, name string) (Player, error)
+	CreateMany(ctx context.Context, pp []NewPlayer, ordered bool) ([]BatchResult, error)
 	Read(ctx context.Context, id xid.ID) (Player, error)
-	Update(ctx context.Context, id xid.ID, email, name string) (Player, error)
-	Delete(ctx context.Context, id xid.ID) error
+	Update(ctx context.Context, id, version xid.ID, email, name string) (Player, error)
+	Patch(ctx context.Context, id, version xid.ID, patch Patch) (Player, error)
+	Delete(ctx context.Context, id, version xid.ID) error
 	List(ctx context.Context) ([]Player, error)
 	Filter(ctx context.Context, req FilterRequest, offset, limit uint) (total uint, pp []Player, err error)
 }
 
+const batchChunkSize = 1000
+
 type service struct {
 	serviceName string
 	storage     Storage
@@ -48,16 +52,85 @@ func (c *service) Create(ctx context.Context, email, name string) (Player, error
 	return p, nil
 }
 
+// CreateMany inserts players in chunks. Per-player failures are reported in
+// results; in ordered mode the first failure skips every following player.
+// When a chunk fails as a whole, its players are reported failed and the
+// following ones skipped, players of earlier chunks stay created.
+func (c *service) CreateMany(ctx context.Context, pp []NewPlayer, ordered bool) ([]BatchResult, error) {
+	results := make([]BatchResult, len(pp))
+	now := time.Now().UTC()
+
+	for i, np := range pp {
+		results[i].Player = Player{
+			ID:        xid.New(),
+			Version:   xid.New(),
+			Email:     np.Email,
+			Name:      np.Name,
+			UpdatedAt: now,
+			CreatedAt: now,
+		}
+	}
+
+	for start := 0; start < len(results); start += batchChunkSize {
+		end := start + batchChunkSize
+		if end > len(results) {
+			end = len(results)
+		}
+
+		chunk := make([]Player, 0, end-start)
+		for _, r := range results[start:end] {
+			chunk = append(chunk, r.Player)
+		}
+
+		errs, err := c.storage.InsertMany(ctx, chunk, ordered)
+		if err != nil {
+			// earlier chunks are committed, so report them instead of failing
+			// the whole batch
+			setErr(results[start:end], fmt.Errorf("insert players: %w", err))
+			setErr(results[end:], ErrSkipped)
+
+			break
+		}
+
+		failed := false
+
+		for i, err := range errs {
+			if err != nil {
+				results[start+i].Err = err
+				failed = true
+			}
+		}
+
+		if ordered && failed {
+			setErr(results[end:], ErrSkipped)
+
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func setErr(results []BatchResult, err error) {
+	for i := range results {
+		results[i].Err = err
+	}
+}
+
 func (c *service) Read(ctx context.Context, id xid.ID) (Player, error) {
 	return c.storage.GetByID(ctx, id)
 }
 
-func (c *service) Update(ctx context.Context, id xid.ID, email, name string) (Player, error) {
+func (c *service) Update(ctx context.Context, id, version xid.ID, email, name string) (Player, error) {
 	oldP, err := c.Read(ctx, id)
 	if err != nil {
 		return oldP, err
 	}
 
+	if !version.IsNil() && oldP.Version != version {
+		return Player{}, ErrVersionMismatch
+	}
+
 	newP := oldP
 	newP.Email = email
 	newP.Name = name
@@ -72,8 +145,43 @@ func (c *service) Update(ctx context.Context, id xid.ID, email, name string) (Pl
 	return p, nil
 }
 
-func (c *service) Delete(ctx context.Context, id xid.ID) error {
-	err := c.storage.Delete(ctx, id)
+func (c *service) Patch(ctx context.Context, id, version xid.ID, patch Patch) (Player, error) {
+	oldP, err := c.Read(ctx, id)
+	if err != nil {
+		return oldP, err
+	}
+
+	if !version.IsNil() && oldP.Version != version {
+		return Player{}, ErrVersionMismatch
+	}
+
+	if patch.Empty() {
+		return oldP, nil
+	}
+
+	newP := oldP
+
+	if patch.Email != nil {
+		newP.Email = *patch.Email
+	}
+
+	if patch.Name != nil {
+		newP.Name = *patch.Name
+	}
+
+	newP.Version = xid.New()
+	newP.UpdatedAt = time.Now().UTC()
+
+	p, err := c.storage.Replace(ctx, oldP, newP)
+	if err != nil {
+		return p, fmt.Errorf("replace player: %w", err)
+	}
+
+	return p, nil
+}
+
+func (c *service) Delete(ctx context.Context, id, version xid.ID) error {
+	err := c.storage.Delete(ctx, id, version)
 	if err != nil {
 		return fmt.Errorf("delete player: %w", err)
 	}
@@ -0,0 +1,88 @@
+package player
+
+import (
+	"00-go-base-tpl-sv/internal/tracing"
+	"context"
+
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+func NewTracedService(next Service, tracer trace.Tracer) Service {
+	return &tracedService{next: next, tracer: tracer}
+}
+
+func (s *tracedService) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "player.Service/"+name, trace.WithAttributes(attrs...))
+}
+
+func idAttr(id xid.ID) attribute.KeyValue {
+	return attribute.String("player.id", id.String())
+}
+
+func (s *tracedService) Create(ctx context.Context, email, name string) (p Player, err error) {
+	ctx, span := s.start(ctx, "Create")
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Create(ctx, email, name)
+}
+
+func (s *tracedService) CreateMany(ctx context.Context, pp []NewPlayer, ordered bool) (res []BatchResult, err error) {
+	ctx, span := s.start(ctx, "CreateMany", attribute.Int("players.count", len(pp)), attribute.Bool("ordered", ordered))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.CreateMany(ctx, pp, ordered)
+}
+
+func (s *tracedService) Read(ctx context.Context, id xid.ID) (p Player, err error) {
+	ctx, span := s.start(ctx, "Read", idAttr(id))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Read(ctx, id)
+}
+
+func (s *tracedService) Update(ctx context.Context, id, version xid.ID, email, name string) (p Player, err error) {
+	ctx, span := s.start(ctx, "Update", idAttr(id))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Update(ctx, id, version, email, name)
+}
+
+func (s *tracedService) Patch(ctx context.Context, id, version xid.ID, patch Patch) (p Player, err error) {
+	ctx, span := s.start(ctx, "Patch", idAttr(id))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Patch(ctx, id, version, patch)
+}
+
+func (s *tracedService) Delete(ctx context.Context, id, version xid.ID) (err error) {
+	ctx, span := s.start(ctx, "Delete", idAttr(id))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s *tracedService) List(ctx context.Context) (pp []Player, err error) {
+	ctx, span := s.start(ctx, "List")
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.List(ctx)
+}
+
+func (s *tracedService) Filter(
+	ctx context.Context,
+	req FilterRequest,
+	offset,
+	limit uint,
+) (total uint, pp []Player, err error) {
+	ctx, span := s.start(ctx, "Filter", attribute.Int("offset", int(offset)), attribute.Int("limit", int(limit)))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Filter(ctx, req, offset, limit)
+}
@@ -13,9 +13,10 @@ import (
 
 type Storage interface {
 	Insert(ctx context.Context, p Player) (Player, error)
+	InsertMany(ctx context.Context, pp []Player, ordered bool) ([]error, error)
 	Replace(ctx context.Context, oldP, newP Player) (Player, error)
 	GetByID(ctx context.Context, id xid.ID) (Player, error)
-	Delete(ctx context.Context, id xid.ID) error
+	Delete(ctx context.Context, id, version xid.ID) error
 	All(ctx context.Context) ([]Player, error)
 	Filter(ctx context.Context, req FilterRequest, offset, limit uint) (total uint, pp []Player, err error)
 }
@@ -24,8 +25,8 @@ type StorageMongo struct {
 	collection *mongo.Collection
 }
 
-func NewStorageMongo() *StorageMongo {
-	return &StorageMongo{}
+func NewStorageMongo(collection *mongo.Collection) *StorageMongo {
+	return &StorageMongo{collection: collection}
 }
 
 func (s *StorageMongo) Insert(ctx context.Context, p Player) (Player, error) {
@@ -37,6 +38,40 @@ func (s *StorageMongo) Insert(ctx context.Context, p Player) (Player, error) {
 	return p, nil
 }
 
+func (s *StorageMongo) InsertMany(ctx context.Context, pp []Player, ordered bool) ([]error, error) {
+	errs := make([]error, len(pp))
+	if len(pp) == 0 {
+		return errs, nil
+	}
+
+	docs := make([]interface{}, 0, len(pp))
+	for _, p := range pp {
+		docs = append(docs, p)
+	}
+
+	_, err := s.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(ordered))
+	if err == nil {
+		return errs, nil
+	}
+
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || len(bwe.WriteErrors) == 0 {
+		return nil, s.convertErr(err)
+	}
+
+	for _, we := range bwe.WriteErrors {
+		errs[we.Index] = s.convertErr(mongo.WriteException{WriteErrors: mongo.WriteErrors{we.WriteError}})
+	}
+
+	if ordered {
+		for i := bwe.WriteErrors[0].Index + 1; i < len(errs); i++ {
+			errs[i] = ErrSkipped
+		}
+	}
+
+	return errs, nil
+}
+
 func (s *StorageMongo) Replace(ctx context.Context, oldP, newP Player) (Player, error) {
 	if oldP.ID != newP.ID {
 		return Player{}, ErrIDMismatch
@@ -72,17 +107,36 @@ func (s *StorageMongo) GetByID(ctx context.Context, id xid.ID) (Player, error) {
 	return p, err
 }
 
-func (s *StorageMongo) Delete(ctx context.Context, id xid.ID) error {
-	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+func (s *StorageMongo) Delete(ctx context.Context, id, version xid.ID) error {
+	filter := bson.M{"_id": id}
+
+	if !version.IsNil() {
+		filter["version"] = version
+	}
+
+	result, err := s.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return s.convertErr(err)
 	}
 
-	if result.DeletedCount == 0 {
+	if result.DeletedCount > 0 {
+		return nil
+	}
+
+	if version.IsNil() {
 		return ErrNotFound
 	}
 
-	return nil
+	count, err := s.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("count players: %w", err)
+	}
+
+	if count == 0 {
+		return ErrNotFound
+	}
+
+	return ErrVersionMismatch
 }
 
 func (s *StorageMongo) All(ctx context.Context) ([]Player, error) {
@@ -156,19 +210,6 @@ func (s *StorageMongo) Filter(
 	return uint(count), pp, nil
 }
 
-func (s *StorageMongo) Setup(ctx context.Context) error {
-	// todo
-	//_, err := s.collection.Indexes().CreateOne(
-	//	ctx,
-	//	mongo.IndexModel{
-	//		Keys:    bson.M{"email": 1},
-	//		Options: options.Index().SetUnique(true).SetName("email_idx"),
-	//	},
-	//)
-
-	return errors.New("")
-}
-
 func (s *StorageMongo) convertErr(err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,358 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+type QuestionStatus string
+
+const (
+	QuestionPublished QuestionStatus = "published"
+	QuestionRetired   QuestionStatus = "retired"
+)
+
+type RevisionStatus string
+
+const (
+	RevisionDraft    RevisionStatus = "draft"
+	RevisionInReview RevisionStatus = "in_review"
+	RevisionApproved RevisionStatus = "approved"
+	RevisionRejected RevisionStatus = "rejected"
+)
+
+// Revision is one edit of a question on its way through review. Approving
+// it publishes its Question, sessions dealt an earlier revision keep their
+// copy of it.
+type Revision struct {
+	ID         xid.ID         `bson:"_id"`
+	Version    xid.ID         `bson:"version"`
+	QuestionID xid.ID         `bson:"question_id"`
+	Number     int            `bson:"number"`
+	Question   Question       `bson:"question"`
+	Status     RevisionStatus `bson:"status"`
+
+	AuthorID   int64  `bson:"author_id"`
+	ReviewerID int64  `bson:"reviewer_id,omitempty"`
+	ReviewNote string `bson:"review_note,omitempty"`
+
+	CreatedAt   time.Time  `bson:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at"`
+	SubmittedAt *time.Time `bson:"submitted_at,omitempty"`
+	ReviewedAt  *time.Time `bson:"reviewed_at,omitempty"`
+}
+
+// Editable reports whether Edit accepts the revision as the latest one of its
+// question.
+func (r Revision) Editable() bool {
+	switch r.Status {
+	case RevisionDraft, RevisionRejected, RevisionApproved:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuthoringService moves questions through draft, review and publishing.
+// Every call acts on the latest revision of the question.
+type AuthoringService interface {
+	// Create starts a new question as a draft of revision 1, with the ID of
+	// q if it has one.
+	Create(ctx context.Context, authorID int64, q Question) (Revision, error)
+	// Edit changes a draft or rejected revision in place, or starts the next
+	// revision when the latest one is approved. An empty external ID keeps
+	// the current one. Both fail with ErrExternalIDTaken when another
+	// question has the external ID.
+	Edit(ctx context.Context, authorID int64, questionID xid.ID, q Question) (Revision, error)
+	Submit(ctx context.Context, authorID int64, questionID xid.ID) (Revision, error)
+	// Approve publishes the revision under review. Reviewers can't approve
+	// their own revisions. Approving an approved revision publishes it again.
+	Approve(ctx context.Context, reviewerID int64, questionID xid.ID) (Revision, error)
+	Reject(ctx context.Context, reviewerID int64, questionID xid.ID, note string) (Revision, error)
+	// Retire takes the published question out of new decks.
+	Retire(ctx context.Context, questionID xid.ID) (Question, error)
+	// Discard deletes a question that never got past its first draft.
+	Discard(ctx context.Context, questionID xid.ID) error
+	Revisions(ctx context.Context, questionID xid.ID) ([]Revision, error)
+	ListByStatus(ctx context.Context, status RevisionStatus) ([]Revision, error)
+	// FindByExternalID resolves an external ID to a question ID. Questions
+	// without one are found by their ID, so exports round-trip.
+	FindByExternalID(ctx context.Context, externalID string) (xid.ID, error)
+	List(ctx context.Context, filter QuestionFilter) ([]Question, error)
+}
+
+// QuestionFilter matches questions, zero values match any.
+type QuestionFilter struct {
+	Status   QuestionStatus
+	Category string
+	Language string
+}
+
+type authoringService struct {
+	questions QuestionStorage
+	revisions RevisionStorage
+}
+
+func NewAuthoringService(questions QuestionStorage, revisions RevisionStorage) AuthoringService {
+	return &authoringService{questions: questions, revisions: revisions}
+}
+
+func (c *authoringService) Create(ctx context.Context, authorID int64, q Question) (Revision, error) {
+	if q.ID.IsNil() {
+		q.ID = xid.New()
+	}
+
+	if err := c.checkExternalID(ctx, q); err != nil {
+		return Revision{}, err
+	}
+
+	return c.insertRevision(ctx, authorID, q, 1)
+}
+
+// checkExternalID keeps an external ID on one question, imports upsert into
+// whichever question has it.
+func (c *authoringService) checkExternalID(ctx context.Context, q Question) error {
+	if q.ExternalID == "" {
+		return nil
+	}
+
+	taken, err := c.revisions.ExternalIDTaken(ctx, q.ExternalID, q.ID)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return fmt.Errorf("%w: %s", ErrExternalIDTaken, q.ExternalID)
+	}
+
+	return nil
+}
+
+func (c *authoringService) Edit(ctx context.Context, authorID int64, questionID xid.ID, q Question) (Revision, error) {
+	q.ID = questionID
+
+	latest, err := c.revisions.GetLatest(ctx, questionID)
+	if errors.Is(err, ErrQuestionNotFound) {
+		// questions from before authoring existed have no revisions
+		published, err := c.questions.Get(ctx, questionID)
+		if err != nil {
+			return Revision{}, err
+		}
+
+		if q.ExternalID == "" {
+			q.ExternalID = published.ExternalID
+		}
+
+		if err := c.checkExternalID(ctx, q); err != nil {
+			return Revision{}, err
+		}
+
+		return c.insertRevision(ctx, authorID, q, published.Revision+1)
+	}
+
+	if err != nil {
+		return Revision{}, err
+	}
+
+	if q.ExternalID == "" {
+		q.ExternalID = latest.Question.ExternalID
+	}
+
+	if !latest.Editable() {
+		return Revision{}, fmt.Errorf("%w: can't edit a revision %s", ErrReviewState, latest.Status)
+	}
+
+	if err := c.checkExternalID(ctx, q); err != nil {
+		return Revision{}, err
+	}
+
+	if latest.Status == RevisionApproved {
+		return c.insertRevision(ctx, authorID, q, latest.Number+1)
+	}
+
+	if err := q.Validate(); err != nil {
+		return Revision{}, err
+	}
+
+	q.Revision = latest.Number
+
+	r := latest
+	r.Version = xid.New()
+	r.Question = q
+	r.Status = RevisionDraft
+	r.AuthorID = authorID
+	r.ReviewerID = 0
+	r.ReviewNote = ""
+	r.UpdatedAt = time.Now().UTC()
+	r.SubmittedAt = nil
+	r.ReviewedAt = nil
+
+	return c.revisions.Replace(ctx, latest, r)
+}
+
+func (c *authoringService) Submit(ctx context.Context, _ int64, questionID xid.ID) (Revision, error) {
+	latest, err := c.revisions.GetLatest(ctx, questionID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	if latest.Status != RevisionDraft {
+		return Revision{}, fmt.Errorf("%w: can't submit a revision %s", ErrReviewState, latest.Status)
+	}
+
+	now := time.Now().UTC()
+
+	r := latest
+	r.Version = xid.New()
+	r.Status = RevisionInReview
+	r.UpdatedAt = now
+	r.SubmittedAt = &now
+
+	return c.revisions.Replace(ctx, latest, r)
+}
+
+func (c *authoringService) Approve(ctx context.Context, reviewerID int64, questionID xid.ID) (Revision, error) {
+	latest, err := c.revisions.GetLatest(ctx, questionID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	r := latest
+
+	switch latest.Status {
+	case RevisionApproved:
+		// a previous approve failed to publish, or a retired question is
+		// brought back
+	case RevisionInReview:
+		if latest.AuthorID == reviewerID {
+			return Revision{}, ErrSelfReview
+		}
+
+		now := time.Now().UTC()
+
+		r.Version = xid.New()
+		r.Status = RevisionApproved
+		r.ReviewerID = reviewerID
+		r.UpdatedAt = now
+		r.ReviewedAt = &now
+
+		// the revision is approved first, so of two concurrent reviews only
+		// one publishes
+		r, err = c.revisions.Replace(ctx, latest, r)
+		if err != nil {
+			return Revision{}, err
+		}
+	default:
+		return Revision{}, fmt.Errorf("%w: can't approve a revision %s", ErrReviewState, latest.Status)
+	}
+
+	q := r.Question
+	q.Revision = r.Number
+	q.Status = QuestionPublished
+
+	if err := c.questions.Publish(ctx, q); err != nil {
+		return Revision{}, fmt.Errorf("publish question: %w", err)
+	}
+
+	return r, nil
+}
+
+func (c *authoringService) Reject(ctx context.Context, reviewerID int64, questionID xid.ID, note string) (Revision, error) {
+	latest, err := c.revisions.GetLatest(ctx, questionID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	if latest.Status != RevisionInReview {
+		return Revision{}, fmt.Errorf("%w: can't reject a revision %s", ErrReviewState, latest.Status)
+	}
+
+	now := time.Now().UTC()
+
+	r := latest
+	r.Version = xid.New()
+	r.Status = RevisionRejected
+	r.ReviewerID = reviewerID
+	r.ReviewNote = note
+	r.UpdatedAt = now
+	r.ReviewedAt = &now
+
+	return c.revisions.Replace(ctx, latest, r)
+}
+
+func (c *authoringService) Retire(ctx context.Context, questionID xid.ID) (Question, error) {
+	return c.questions.SetStatus(ctx, questionID, QuestionRetired)
+}
+
+func (c *authoringService) Discard(ctx context.Context, questionID xid.ID) error {
+	latest, err := c.revisions.GetLatest(ctx, questionID)
+	if err != nil {
+		return err
+	}
+
+	if latest.Number != 1 || latest.Status != RevisionDraft {
+		return fmt.Errorf("%w: can't discard revision %d %s", ErrReviewState, latest.Number, latest.Status)
+	}
+
+	return c.revisions.Delete(ctx, latest)
+}
+
+func (c *authoringService) Revisions(ctx context.Context, questionID xid.ID) ([]Revision, error) {
+	return c.revisions.ListByQuestion(ctx, questionID)
+}
+
+func (c *authoringService) ListByStatus(ctx context.Context, status RevisionStatus) ([]Revision, error) {
+	return c.revisions.ListByStatus(ctx, status)
+}
+
+func (c *authoringService) FindByExternalID(ctx context.Context, externalID string) (xid.ID, error) {
+	r, err := c.revisions.GetLatestByExternalID(ctx, externalID)
+	if err == nil {
+		return r.QuestionID, nil
+	}
+
+	if !errors.Is(err, ErrQuestionNotFound) {
+		return xid.ID{}, err
+	}
+
+	id, err := xid.FromString(externalID)
+	if err != nil {
+		return xid.ID{}, ErrQuestionNotFound
+	}
+
+	q, err := c.questions.Get(ctx, id)
+	if err != nil {
+		return xid.ID{}, err
+	}
+
+	return q.ID, nil
+}
+
+func (c *authoringService) List(ctx context.Context, filter QuestionFilter) ([]Question, error) {
+	return c.questions.List(ctx, filter)
+}
+
+func (c *authoringService) insertRevision(ctx context.Context, authorID int64, q Question, number int) (Revision, error) {
+	if err := q.Validate(); err != nil {
+		return Revision{}, err
+	}
+
+	q.Revision = number
+	now := time.Now().UTC()
+
+	return c.revisions.Insert(ctx, Revision{
+		ID:         xid.New(),
+		Version:    xid.New(),
+		QuestionID: q.ID,
+		Number:     number,
+		Question:   q,
+		Status:     RevisionDraft,
+		AuthorID:   authorID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
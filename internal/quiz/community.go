@@ -0,0 +1,281 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+type CommunityStatus string
+
+const (
+	// CommunitySubmitting reserves a submission of the player until its
+	// revision is in review. Nobody votes on it, yet it counts against the
+	// daily limit.
+	CommunitySubmitting CommunityStatus = "submitting"
+	CommunityPending    CommunityStatus = "pending"
+	CommunityPromoted   CommunityStatus = "promoted"
+	CommunityDemoted    CommunityStatus = "demoted"
+)
+
+const (
+	VoteUp   = 1
+	VoteDown = -1
+)
+
+// communityReviewerID approves and rejects revisions on behalf of the
+// community, no Telegram user has it.
+const communityReviewerID int64 = 0
+
+const (
+	submissionWindow     = 24 * time.Hour
+	communityQueueLength = 20
+	demotedByReportsNote = "demoted by community reports"
+	demotedByVotesNote   = "demoted by community votes"
+)
+
+// CommunityQuestion tallies votes and reports on a question a player
+// submitted. The question itself goes through review as a revision.
+type CommunityQuestion struct {
+	ID        xid.ID          `bson:"_id"`
+	AuthorID  int64           `bson:"author_id"`
+	Question  Question        `bson:"question"`
+	Status    CommunityStatus `bson:"status"`
+	Upvotes   int             `bson:"upvotes"`
+	Downvotes int             `bson:"downvotes"`
+	Reports   int             `bson:"reports"`
+	CreatedAt time.Time       `bson:"created_at"`
+	UpdatedAt time.Time       `bson:"updated_at"`
+}
+
+func (c CommunityQuestion) Score() int {
+	return c.Upvotes - c.Downvotes
+}
+
+type CommunityConfig struct {
+	// SubmissionsPerDay limits submissions of a player in 24 hours.
+	SubmissionsPerDay int
+	// PromoteScore of upvotes minus downvotes publishes the question,
+	// the negative of it rejects the question.
+	PromoteScore int
+	// DemoteReports retires the question, even a promoted one.
+	DemoteReports int
+}
+
+// CommunityService lets players submit questions and decide by votes which
+// make it into the bank. Questions have the ID of their revision's question.
+type CommunityService interface {
+	Submit(ctx context.Context, telegramID int64, q Question) (CommunityQuestion, error)
+	// Queue lists pending questions of other players.
+	Queue(ctx context.Context, telegramID int64) ([]CommunityQuestion, error)
+	// Vote replaces an earlier vote of the player on the question.
+	Vote(ctx context.Context, telegramID int64, id xid.ID, vote int) (CommunityQuestion, error)
+	Report(ctx context.Context, telegramID int64, id xid.ID, reason string) (CommunityQuestion, error)
+}
+
+type communityService struct {
+	authoring AuthoringService
+	storage   CommunityStorage
+	config    CommunityConfig
+}
+
+func NewCommunityService(authoring AuthoringService, storage CommunityStorage, config CommunityConfig) CommunityService {
+	return &communityService{authoring: authoring, storage: storage, config: config}
+}
+
+// Submit reserves the submission before counting, so concurrent submissions
+// can't exceed the limit, and creates the revision only once reserved.
+func (c *communityService) Submit(ctx context.Context, telegramID int64, q Question) (CommunityQuestion, error) {
+	q.ID = xid.New()
+	// external IDs belong to content teams
+	q.ExternalID = ""
+
+	if err := q.Validate(); err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	now := time.Now().UTC()
+
+	cq, err := c.storage.Insert(ctx, CommunityQuestion{
+		ID:        q.ID,
+		AuthorID:  telegramID,
+		Question:  q,
+		Status:    CommunitySubmitting,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	submitted, err := c.storage.CountByAuthorSince(ctx, telegramID, now.Add(-submissionWindow))
+	if err != nil {
+		return CommunityQuestion{}, c.cancelSubmission(ctx, cq, fmt.Errorf("count submissions: %w", err))
+	}
+
+	if submitted > c.config.SubmissionsPerDay {
+		return CommunityQuestion{}, c.cancelSubmission(ctx, cq, ErrRateLimited)
+	}
+
+	rev, err := c.authoring.Create(ctx, telegramID, q)
+	if err != nil {
+		return CommunityQuestion{}, c.cancelSubmission(ctx, cq, err)
+	}
+
+	if _, err := c.authoring.Submit(ctx, telegramID, rev.QuestionID); err != nil {
+		err = fmt.Errorf("submit for review: %w", err)
+
+		// nobody can vote on the draft, so it goes with the reservation
+		if discardErr := c.authoring.Discard(ctx, rev.QuestionID); discardErr != nil {
+			err = errors.Join(err, fmt.Errorf("discard draft: %w", discardErr))
+		}
+
+		return CommunityQuestion{}, c.cancelSubmission(ctx, cq, err)
+	}
+
+	cq, ok, err := c.storage.SetStatus(ctx, cq.ID, CommunitySubmitting, CommunityPending)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if !ok {
+		return CommunityQuestion{}, fmt.Errorf("community question %s is no longer submitting", q.ID)
+	}
+
+	return cq, nil
+}
+
+// cancelSubmission frees the reserved slot, a reservation left behind only
+// counts against the limit of the player until the window passes.
+func (c *communityService) cancelSubmission(ctx context.Context, cq CommunityQuestion, err error) error {
+	if delErr := c.storage.Delete(ctx, cq.ID); delErr != nil {
+		return errors.Join(err, fmt.Errorf("cancel submission: %w", delErr))
+	}
+
+	return err
+}
+
+func (c *communityService) Queue(ctx context.Context, telegramID int64) ([]CommunityQuestion, error) {
+	return c.storage.ListPending(ctx, telegramID, communityQueueLength)
+}
+
+func (c *communityService) Vote(ctx context.Context, telegramID int64, id xid.ID, vote int) (CommunityQuestion, error) {
+	if vote != VoteUp && vote != VoteDown {
+		return CommunityQuestion{}, fmt.Errorf("%w: vote must be %d or %d", ErrInvalidVote, VoteUp, VoteDown)
+	}
+
+	cq, err := c.votable(ctx, telegramID, id)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if cq.Status != CommunityPending {
+		return CommunityQuestion{}, ErrVotingClosed
+	}
+
+	cq, err = c.storage.Vote(ctx, id, telegramID, vote)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	switch {
+	case cq.Score() >= c.config.PromoteScore:
+		return c.promote(ctx, cq)
+	case cq.Score() <= -c.config.PromoteScore:
+		return c.demote(ctx, cq, demotedByVotesNote)
+	default:
+		return cq, nil
+	}
+}
+
+// Report works on promoted questions too, so bad ones are taken out of the
+// bank without waiting for an admin.
+func (c *communityService) Report(ctx context.Context, telegramID int64, id xid.ID, reason string) (CommunityQuestion, error) {
+	cq, err := c.votable(ctx, telegramID, id)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if cq.Status == CommunityDemoted || cq.Status == CommunitySubmitting {
+		return CommunityQuestion{}, ErrVotingClosed
+	}
+
+	cq, err = c.storage.Report(ctx, id, telegramID, reason)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if cq.Reports >= c.config.DemoteReports {
+		return c.demote(ctx, cq, demotedByReportsNote)
+	}
+
+	return cq, nil
+}
+
+func (c *communityService) votable(ctx context.Context, telegramID int64, id xid.ID) (CommunityQuestion, error) {
+	cq, err := c.storage.Get(ctx, id)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if cq.AuthorID == telegramID {
+		return CommunityQuestion{}, ErrOwnQuestion
+	}
+
+	return cq, nil
+}
+
+// promote and demote change the status first, so of concurrent votes
+// crossing a threshold only one acts on the revision. A failed approve puts
+// the question back to pending, the next vote crossing the threshold retries
+// it.
+func (c *communityService) promote(ctx context.Context, cq CommunityQuestion) (CommunityQuestion, error) {
+	updated, ok, err := c.storage.SetStatus(ctx, cq.ID, CommunityPending, CommunityPromoted)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if !ok {
+		return cq, nil
+	}
+
+	if _, err := c.authoring.Approve(ctx, communityReviewerID, cq.ID); err != nil {
+		err = fmt.Errorf("approve promoted question: %w", err)
+
+		if _, _, rollbackErr := c.storage.SetStatus(ctx, cq.ID, CommunityPromoted, CommunityPending); rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf("roll back promotion: %w", rollbackErr))
+		}
+
+		return CommunityQuestion{}, err
+	}
+
+	return updated, nil
+}
+
+func (c *communityService) demote(ctx context.Context, cq CommunityQuestion, note string) (CommunityQuestion, error) {
+	updated, ok, err := c.storage.SetStatus(ctx, cq.ID, cq.Status, CommunityDemoted)
+	if err != nil {
+		return CommunityQuestion{}, err
+	}
+
+	if !ok {
+		return cq, nil
+	}
+
+	cq = updated
+
+	// an admin may have approved it meanwhile, then it is retired instead
+	_, err = c.authoring.Reject(ctx, communityReviewerID, cq.ID, note)
+	if errors.Is(err, ErrReviewState) {
+		_, err = c.authoring.Retire(ctx, cq.ID)
+	}
+
+	if err != nil && !errors.Is(err, ErrQuestionNotFound) {
+		return CommunityQuestion{}, fmt.Errorf("demote question: %w", err)
+	}
+
+	return cq, nil
+}
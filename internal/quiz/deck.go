@@ -0,0 +1,85 @@
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// deckPoolFactor is how many candidates per deck slot are sampled from
+	// storage before weighting.
+	deckPoolFactor = 10
+
+	// recentSessionsLimit caps how many past sessions count as recent.
+	recentSessionsLimit = 50
+
+	neighbourDifficultyWeight = 0.35
+	recentlySeenWeight        = 0.05
+)
+
+// DeckRequest narrows the questions of a session, zero values match any.
+// Difficulty is a target: neighbouring levels are picked too, less often.
+type DeckRequest struct {
+	Category   string `bson:"category,omitempty"`
+	Difficulty int    `bson:"difficulty,omitempty"`
+	Language   string `bson:"language,omitempty"`
+}
+
+func (r DeckRequest) Validate() error {
+	if r.Difficulty != 0 && (r.Difficulty < MinDifficulty || r.Difficulty > MaxDifficulty) {
+		return fmt.Errorf("%w: difficulty must be between %d and %d", ErrInvalidDeck, MinDifficulty, MaxDifficulty)
+	}
+
+	return nil
+}
+
+type Category struct {
+	Name      string `bson:"_id"`
+	Questions int    `bson:"questions"`
+}
+
+// pickDeck draws n questions without replacement, weighted by how close
+// their difficulty is to the requested one and whether the player saw them
+// recently. Seen questions are only likely once fresh ones run out.
+func pickDeck(candidates []Question, req DeckRequest, seen map[xid.ID]bool, n int) []Question {
+	type keyed struct {
+		key float64
+		q   Question
+	}
+
+	keys := make([]keyed, 0, len(candidates))
+
+	for _, q := range candidates {
+		w := 1.0
+
+		if req.Difficulty != 0 && q.Difficulty != req.Difficulty {
+			w *= neighbourDifficultyWeight
+		}
+
+		if seen[q.ID] {
+			w *= recentlySeenWeight
+		}
+
+		// Efraimidis-Spirakis: the n largest u^(1/w) are a weighted sample
+		keys = append(keys, keyed{key: math.Pow(rand.Float64(), 1/w), q: q}) // nolint:gosec not a security concern
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].key > keys[j].key
+	})
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	deck := make([]Question, 0, len(keys))
+	for _, k := range keys {
+		deck = append(deck, k.q)
+	}
+
+	return deck
+}
@@ -0,0 +1,33 @@
+package quiz
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound         = errors.New("quiz session not found")
+	ErrNoQuestions      = errors.New("no questions available")
+	ErrFinished         = errors.New("quiz session is finished")
+	ErrQuestionMismatch = errors.New("answer is not for the current question")
+	ErrInvalidOption    = errors.New("unknown option")
+	ErrInvalidResponse  = errors.New("response doesn't fit the question type")
+	ErrInvalidQuestion  = errors.New("invalid question")
+	ErrInvalidDeck      = errors.New("invalid deck request")
+	ErrVersionMismatch  = errors.New("quiz session was changed concurrently")
+	ErrSessionActive    = errors.New("player already has an active quiz session")
+
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrReviewState      = errors.New("question revision is in the wrong state")
+	ErrSelfReview       = errors.New("reviewer can't approve own revision")
+	ErrRevisionConflict = errors.New("question revision was changed concurrently")
+	ErrExternalIDTaken  = errors.New("external id belongs to another question")
+
+	ErrRateLimited     = errors.New("too many questions submitted, try again later")
+	ErrOwnQuestion     = errors.New("can't vote on own question")
+	ErrInvalidVote     = errors.New("invalid vote")
+	ErrVotingClosed    = errors.New("community question is no longer open for votes")
+	ErrAlreadyReported = errors.New("community question is already reported by the player")
+
+	ErrInvalidTranslation  = errors.New("invalid translation")
+	ErrTranslationNotFound = errors.New("translation not found")
+)
@@ -0,0 +1,316 @@
+package quiz
+
+import (
+	"00-go-base-tpl-sv/internal/migration"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collections names the collections of the quiz package.
+type Collections struct {
+	Question          string
+	Session           string
+	Revision          string
+	CommunityQuestion string
+	CommunityVote     string
+	Translation       string
+}
+
+func Migrations(c Collections) []migration.Migration {
+
+	return []migration.Migration{
+		{
+			Version: 2023102001,
+			Name:    "quiz_session_active_index",
+			Up: migration.CreateIndex(c.Session, mongo.IndexModel{
+				Keys:    bson.D{{Key: "telegram_id", Value: 1}, {Key: "finished_at", Value: 1}},
+				Options: options.Index().SetName("telegram_id_finished_at_idx"),
+			}),
+		},
+		{
+			Version: 2023102002,
+			Name:    "quiz_seed_demo_questions",
+			Up:      seedQuestions(c.Question, demoQuestions()),
+		},
+		{
+			Version: 2023102101,
+			Name:    "quiz_question_options_with_ids",
+			Up: migration.UpdateMany(
+				c.Question,
+				bson.M{"correct_option": bson.M{"$exists": true}},
+				mongo.Pipeline{
+					{{Key: "$set", Value: legacyQuestionToSingleChoice("$$ROOT")}},
+					{{Key: "$unset", Value: "correct_option"}},
+				},
+			),
+		},
+		{
+			Version: 2023102102,
+			Name:    "quiz_session_options_with_ids",
+			Up: migration.UpdateMany(
+				c.Session,
+				bson.M{"questions.correct_option": bson.M{"$exists": true}},
+				mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{
+						"questions": bson.M{"$map": bson.M{
+							"input": "$questions",
+							"as":    "q",
+							"in": bson.M{"$mergeObjects": bson.A{
+								bson.M{"_id": "$$q._id", "text": "$$q.text"},
+								legacyQuestionToSingleChoice("$$q"),
+							}},
+						}},
+						"answers": bson.M{"$map": bson.M{
+							"input": "$answers",
+							"as":    "a",
+							"in": bson.M{"$mergeObjects": bson.A{
+								"$$a",
+								bson.M{
+									"option_ids": bson.M{"$cond": bson.A{
+										bson.M{"$eq": bson.A{"$$a.option", -1}},
+										bson.A{},
+										bson.A{bson.M{"$toString": "$$a.option"}},
+									}},
+									"credit": bson.M{"$cond": bson.A{"$$a.correct", 1, 0}},
+									"points": bson.M{"$cond": bson.A{"$$a.correct", PointsPerQuestion, 0}},
+								},
+							}},
+						}},
+						"score": bson.M{"$multiply": bson.A{"$score", PointsPerQuestion}},
+					}}},
+					{{Key: "$unset", Value: "answers.option"}},
+				},
+			),
+		},
+		{
+			Version: 2023102201,
+			Name:    "quiz_question_deck_fields",
+			Up: migration.UpdateMany(
+				c.Question,
+				bson.M{"category": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{
+					"category":   defaultCategory,
+					"tags":       bson.A{},
+					"difficulty": defaultDifficulty,
+					"language":   defaultLanguage,
+				}},
+			),
+		},
+		{
+			Version: 2023102202,
+			Name:    "quiz_question_deck_index",
+			Up: migration.CreateIndex(c.Question, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "language", Value: 1},
+					{Key: "category", Value: 1},
+					{Key: "difficulty", Value: 1},
+				},
+				Options: options.Index().SetName("language_category_difficulty_idx"),
+			}),
+		},
+		{
+			Version: 2023102203,
+			Name:    "quiz_session_recent_index",
+			Up: migration.CreateIndex(c.Session, mongo.IndexModel{
+				Keys:    bson.D{{Key: "telegram_id", Value: 1}, {Key: "started_at", Value: -1}},
+				Options: options.Index().SetName("telegram_id_started_at_idx"),
+			}),
+		},
+		{
+			Version: 2023102301,
+			Name:    "quiz_question_publish_status",
+			Up: migration.UpdateMany(
+				c.Question,
+				bson.M{"status": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"status": QuestionPublished, "revision": 1}},
+			),
+		},
+		{
+			Version: 2023102302,
+			Name:    "quiz_question_revision_number_index",
+			Up: migration.CreateIndex(c.Revision, mongo.IndexModel{
+				Keys:    bson.D{{Key: "question_id", Value: 1}, {Key: "number", Value: -1}},
+				Options: options.Index().SetUnique(true).SetName("question_id_number_idx"),
+			}),
+		},
+		{
+			Version: 2023102303,
+			Name:    "quiz_question_revision_status_index",
+			Up: migration.CreateIndex(c.Revision, mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+				Options: options.Index().SetName("status_updated_at_idx"),
+			}),
+		},
+		{
+			Version: 2023102401,
+			Name:    "quiz_question_revision_external_id_index",
+			Up: migration.CreateIndex(c.Revision, mongo.IndexModel{
+				Keys: bson.D{{Key: "question.external_id", Value: 1}, {Key: "number", Value: -1}},
+				Options: options.Index().
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"question.external_id": bson.M{"$exists": true}}).
+					SetName("question_external_id_number_idx"),
+			}),
+		},
+		{
+			Version: 2023102501,
+			Name:    "quiz_community_question_pending_index",
+			Up: migration.CreateIndex(c.CommunityQuestion, mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+				Options: options.Index().SetName("status_created_at_idx"),
+			}),
+		},
+		{
+			Version: 2023102502,
+			Name:    "quiz_community_question_author_index",
+			Up: migration.CreateIndex(c.CommunityQuestion, mongo.IndexModel{
+				Keys:    bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("author_id_created_at_idx"),
+			}),
+		},
+		{
+			Version: 2023102503,
+			Name:    "quiz_community_vote_unique_index",
+			Up: migration.CreateIndex(c.CommunityVote, mongo.IndexModel{
+				Keys:    bson.D{{Key: "question_id", Value: 1}, {Key: "telegram_id", Value: 1}},
+				Options: options.Index().SetUnique(true).SetName("question_id_telegram_id_idx"),
+			}),
+		},
+		{
+			Version: 2023102601,
+			Name:    "quiz_translation_unique_index",
+			Up: migration.CreateIndex(c.Translation, mongo.IndexModel{
+				Keys:    bson.D{{Key: "question_id", Value: 1}, {Key: "locale", Value: 1}},
+				Options: options.Index().SetUnique(true).SetName("question_id_locale_idx"),
+			}),
+		},
+		{
+			Version: 2023102701,
+			Name:    "quiz_session_close_duplicate_active",
+			Up:      closeDuplicateActiveSessions(c.Session),
+		},
+		{
+			Version: 2023102702,
+			Name:    "quiz_session_active_unique_index",
+			Up: migration.CreateIndex(c.Session, mongo.IndexModel{
+				Keys: bson.D{{Key: "telegram_id", Value: 1}},
+				Options: options.Index().
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"finished_at": bson.M{"$type": "null"}}).
+					SetName("telegram_id_active_idx"),
+			}),
+		},
+	}
+}
+
+// Questions written before decks existed are all about Dota 2, in English.
+const (
+	defaultCategory   = "dota2"
+	defaultDifficulty = 2
+	defaultLanguage   = "en"
+)
+
+// legacyQuestionToSingleChoice converts a question with option texts and a
+// correct_option index into a single-choice one, using indexes as option IDs.
+func legacyQuestionToSingleChoice(q string) bson.M {
+	return bson.M{
+		"type": TypeSingleChoice,
+		"options": bson.M{"$map": bson.M{
+			"input": bson.M{"$range": bson.A{0, bson.M{"$size": q + ".options"}}},
+			"as":    "i",
+			"in": bson.M{
+				"id":      bson.M{"$toString": "$$i"},
+				"text":    bson.M{"$arrayElemAt": bson.A{q + ".options", "$$i"}},
+				"correct": bson.M{"$eq": bson.A{"$$i", q + ".correct_option"}},
+			},
+		}},
+	}
+}
+
+// demoQuestions keep a fresh install playable until questions are authored.
+// They are in the original shape, later migrations convert them.
+func demoQuestions() []bson.M {
+	return []bson.M{
+		{
+			"text":           "What hero has finger?",
+			"options":        bson.A{"Lina", "Lion", "Templar Assasin", "Spirit braker"},
+			"correct_option": 1,
+		},
+		{
+			"text":           "What can dive?",
+			"options":        bson.A{"Pudge", "Io", "Ember spirit", "Phoenix"},
+			"correct_option": 3,
+		},
+	}
+}
+
+// closeDuplicateActiveSessions abandons all but the latest active session of
+// every player, which concurrent starts could create before the unique index.
+func closeDuplicateActiveSessions(collection string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		cursor, err := db.Collection(collection).Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"finished_at": nil}}},
+			{{Key: "$sort", Value: bson.M{"started_at": -1}}},
+			{{Key: "$group", Value: bson.M{"_id": "$telegram_id", "ids": bson.M{"$push": "$_id"}}}},
+			{{Key: "$match", Value: bson.M{"ids.1": bson.M{"$exists": true}}}},
+		})
+		if err != nil {
+			return fmt.Errorf("find duplicate active sessions: %w", err)
+		}
+
+		var players []struct {
+			IDs []xid.ID `bson:"ids"`
+		}
+
+		if err := cursor.All(ctx, &players); err != nil {
+			return fmt.Errorf("cursor convert all: %w", err)
+		}
+
+		now := time.Now().UTC()
+
+		for _, p := range players {
+			_, err := db.Collection(collection).UpdateMany(
+				ctx,
+				bson.M{"_id": bson.M{"$in": p.IDs[1:]}, "finished_at": nil},
+				bson.M{"$set": bson.M{"version": xid.New(), "finished_at": now, "outcome": OutcomeAbandoned}},
+			)
+			if err != nil {
+				return fmt.Errorf("abandon duplicate active sessions: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// seedQuestions upserts by text, so rerunning it never duplicates questions.
+func seedQuestions(collection string, questions []bson.M) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, q := range questions {
+			doc := bson.M{"_id": xid.New()}
+			for k, v := range q {
+				if k != "text" {
+					doc[k] = v
+				}
+			}
+
+			_, err := db.Collection(collection).UpdateOne(
+				ctx,
+				bson.M{"text": q["text"]},
+				bson.M{"$setOnInsert": doc},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return fmt.Errorf("seed question %q: %w", q["text"], err)
+			}
+		}
+
+		return nil
+	}
+}
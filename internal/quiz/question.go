@@ -0,0 +1,337 @@
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+type QuestionType string
+
+const (
+	TypeSingleChoice   QuestionType = "single_choice"
+	TypeMultipleChoice QuestionType = "multiple_choice"
+	TypeTrueFalse      QuestionType = "true_false"
+	TypeNumeric        QuestionType = "numeric"
+)
+
+const (
+	OptionTrue  = "true"
+	OptionFalse = "false"
+
+	MinOptions = 2
+	MaxOptions = 10
+
+	MinDifficulty = 1
+	MaxDifficulty = 5
+
+	MaxTags = 10
+
+	MaxExternalIDLength = 128
+)
+
+type MediaKind string
+
+const (
+	MediaImage MediaKind = "image"
+	MediaVideo MediaKind = "video"
+	MediaAudio MediaKind = "audio"
+)
+
+type Option struct {
+	ID      string `bson:"id"`
+	Text    string `bson:"text"`
+	Correct bool   `bson:"correct"`
+}
+
+type Media struct {
+	Kind MediaKind `bson:"kind"`
+	URL  string    `bson:"url"`
+	Alt  string    `bson:"alt,omitempty"`
+}
+
+// NumericAnswer accepts numbers within Tolerance of Value, inclusive.
+type NumericAnswer struct {
+	Value     float64 `bson:"value"`
+	Tolerance float64 `bson:"tolerance"`
+	Unit      string  `bson:"unit,omitempty"`
+}
+
+type Question struct {
+	ID      xid.ID         `bson:"_id"`
+	Type    QuestionType   `bson:"type"`
+	Text    string         `bson:"text"`
+	Media   *Media         `bson:"media,omitempty"`
+	Options []Option       `bson:"options,omitempty"`
+	Numeric *NumericAnswer `bson:"numeric,omitempty"`
+
+	Category string   `bson:"category"`
+	Tags     []string `bson:"tags"`
+	// Difficulty goes from MinDifficulty, trivial, to MaxDifficulty, expert.
+	Difficulty int `bson:"difficulty"`
+	// Language is an ISO 639-1 code of Text and Options.
+	Language string `bson:"language"`
+
+	// Revision is the approved revision this copy comes from.
+	Revision int            `bson:"revision"`
+	Status   QuestionStatus `bson:"status"`
+	// ExternalID identifies the question in content sources like
+	// spreadsheets, imports upsert by it.
+	ExternalID string `bson:"external_id,omitempty"`
+}
+
+// Validate checks the shape rules of the question type. It reports every
+// broken rule by field in a *ValidationError.
+func (q Question) Validate() error {
+	var v fieldErrors
+
+	if q.Text == "" {
+		v.add("text", "is required")
+	}
+
+	if q.Category == "" {
+		v.add("category", "is required")
+	}
+
+	if len(q.ExternalID) > MaxExternalIDLength {
+		v.add("external_id", "must be at most %d bytes", MaxExternalIDLength)
+	}
+
+	if q.Difficulty < MinDifficulty || q.Difficulty > MaxDifficulty {
+		v.add("difficulty", "must be between %d and %d", MinDifficulty, MaxDifficulty)
+	}
+
+	if len(q.Language) != 2 || strings.ToLower(q.Language) != q.Language {
+		v.add("language", "must be a lowercase ISO 639-1 code")
+	}
+
+	if len(q.Tags) > MaxTags {
+		v.add("tags", "must have at most %d tags", MaxTags)
+	}
+
+	for i, tag := range q.Tags {
+		if tag == "" {
+			v.add(fmt.Sprintf("tags[%d]", i), "must not be empty")
+		}
+	}
+
+	if q.Media != nil {
+		switch q.Media.Kind {
+		case MediaImage, MediaVideo, MediaAudio:
+		default:
+			v.add("media.kind", "unknown media kind %q", q.Media.Kind)
+		}
+
+		if u, err := url.Parse(q.Media.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			v.add("media.url", "must be an http(s) URL")
+		}
+	}
+
+	switch q.Type {
+	case TypeSingleChoice:
+		q.validateOptions(&v, MinOptions, MaxOptions, 1, 1)
+	case TypeMultipleChoice:
+		q.validateOptions(&v, MinOptions, MaxOptions, 1, MaxOptions)
+	case TypeTrueFalse:
+		q.validateOptions(&v, 2, 2, 1, 1)
+
+		for i, o := range q.Options {
+			if o.ID != OptionTrue && o.ID != OptionFalse {
+				v.add(fmt.Sprintf("options[%d].id", i), "must be %q or %q", OptionTrue, OptionFalse)
+			}
+		}
+	case TypeNumeric:
+		if len(q.Options) > 0 {
+			v.add("options", "must be empty for a numeric question")
+		}
+
+		if q.Numeric == nil {
+			v.add("numeric", "is required")
+		} else {
+			if math.IsNaN(q.Numeric.Value) || math.IsInf(q.Numeric.Value, 0) {
+				v.add("numeric.value", "must be finite")
+			}
+
+			if q.Numeric.Tolerance < 0 {
+				v.add("numeric.tolerance", "must not be negative")
+			}
+		}
+	default:
+		v.add("type", "unknown question type %q", q.Type)
+	}
+
+	return v.err(ErrInvalidQuestion)
+}
+
+func (q Question) validateOptions(v *fieldErrors, minOptions, maxOptions, minCorrect, maxCorrect int) {
+	if len(q.Options) < minOptions || len(q.Options) > maxOptions {
+		v.add("options", "must have %d to %d options", minOptions, maxOptions)
+	}
+
+	if q.Numeric != nil {
+		v.add("numeric", "must be empty for a choice question")
+	}
+
+	ids := make(map[string]bool, len(q.Options))
+	texts := make(map[string]bool, len(q.Options))
+	correct := 0
+
+	for i, o := range q.Options {
+		field := fmt.Sprintf("options[%d]", i)
+
+		switch {
+		case o.ID == "":
+			v.add(field+".id", "is required")
+		case ids[o.ID]:
+			v.add(field+".id", "%q is not unique", o.ID)
+		}
+
+		ids[o.ID] = true
+
+		// players can't tell apart options differing in case or spacing only
+		text := strings.ToLower(strings.Join(strings.Fields(o.Text), " "))
+
+		switch {
+		case text == "":
+			v.add(field+".text", "is required")
+		case texts[text]:
+			v.add(field+".text", "%q is not unique", o.Text)
+		}
+
+		texts[text] = true
+
+		if o.Correct {
+			correct++
+		}
+	}
+
+	if correct < minCorrect || correct > maxCorrect {
+		v.add("options", "must have %d to %d correct options", minCorrect, maxCorrect)
+	}
+}
+
+func (q Question) CorrectOptionIDs() []string {
+	ids := make([]string, 0, 1)
+
+	for _, o := range q.Options {
+		if o.Correct {
+			ids = append(ids, o.ID)
+		}
+	}
+
+	return ids
+}
+
+// Grade returns the credit for r between 0 and 1. Single-choice, true/false
+// and numeric answers are all or nothing. Multiple-choice answers get the
+// share of correct options picked minus the share of wrong ones picked, so
+// selecting everything earns nothing.
+func (q Question) Grade(r Response) (float64, error) {
+	if err := q.checkResponse(r); err != nil {
+		return 0, err
+	}
+
+	if r.Empty() {
+		return 0, nil
+	}
+
+	switch q.Type {
+	case TypeNumeric:
+		if math.Abs(*r.Number-q.Numeric.Value) <= q.Numeric.Tolerance {
+			return 1, nil
+		}
+
+		return 0, nil
+	case TypeMultipleChoice:
+		var (
+			picked       = make(map[string]bool, len(r.OptionIDs))
+			correct      int
+			hits, misses int
+		)
+
+		for _, id := range r.OptionIDs {
+			picked[id] = true
+		}
+
+		for _, o := range q.Options {
+			if o.Correct {
+				correct++
+			}
+
+			switch {
+			case picked[o.ID] && o.Correct:
+				hits++
+			case picked[o.ID]:
+				misses++
+			}
+		}
+
+		wrong := len(q.Options) - correct
+		credit := float64(hits) / float64(correct)
+
+		if wrong > 0 {
+			credit -= float64(misses) / float64(wrong)
+		}
+
+		return math.Max(0, credit), nil
+	default:
+		for _, o := range q.Options {
+			if o.ID == r.OptionIDs[0] {
+				if o.Correct {
+					return 1, nil
+				}
+
+				return 0, nil
+			}
+		}
+
+		return 0, nil
+	}
+}
+
+// checkResponse rejects responses which don't fit the question type.
+func (q Question) checkResponse(r Response) error {
+	if q.Type == TypeNumeric {
+		if len(r.OptionIDs) > 0 {
+			return fmt.Errorf("%w: numeric question takes a number", ErrInvalidResponse)
+		}
+
+		if r.Number != nil && (math.IsNaN(*r.Number) || math.IsInf(*r.Number, 0)) {
+			return fmt.Errorf("%w: number must be finite", ErrInvalidResponse)
+		}
+
+		return nil
+	}
+
+	if r.Number != nil {
+		return fmt.Errorf("%w: choice question takes option ids", ErrInvalidResponse)
+	}
+
+	if q.Type != TypeMultipleChoice && len(r.OptionIDs) > 1 {
+		return fmt.Errorf("%w: only one option may be picked", ErrInvalidResponse)
+	}
+
+	known := make(map[string]bool, len(q.Options))
+	for _, o := range q.Options {
+		known[o.ID] = true
+	}
+
+	seen := make(map[string]bool, len(r.OptionIDs))
+
+	for _, id := range r.OptionIDs {
+		if !known[id] {
+			return fmt.Errorf("%w: %q", ErrInvalidOption, id)
+		}
+
+		if seen[id] {
+			return fmt.Errorf("%w: option %q picked twice", ErrInvalidResponse, id)
+		}
+
+		seen[id] = true
+	}
+
+	return nil
+}
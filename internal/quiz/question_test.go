@@ -0,0 +1,114 @@
+package quiz
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQuestionValidateFields(t *testing.T) {
+	valid := func() Question {
+		return Question{
+			Type:       TypeSingleChoice,
+			Text:       "Capital of France?",
+			Category:   "geography",
+			Difficulty: MinDifficulty,
+			Language:   "en",
+			Options: []Option{
+				{ID: "a", Text: "Paris", Correct: true},
+				{ID: "b", Text: "Lyon"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(q *Question)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*Question) {},
+		},
+		{
+			name: "duplicate option id and text",
+			modify: func(q *Question) {
+				q.Options = append(q.Options, Option{ID: "b", Text: " paris "})
+			},
+			want: []string{"options[2].id", "options[2].text"},
+		},
+		{
+			name: "wrong correct option count",
+			modify: func(q *Question) {
+				q.Options[1].Correct = true
+			},
+			want: []string{"options"},
+		},
+		{
+			name: "blank option text",
+			modify: func(q *Question) {
+				q.Options[1].Text = "  "
+			},
+			want: []string{"options[1].text"},
+		},
+		{
+			name: "true/false option ids",
+			modify: func(q *Question) {
+				q.Type = TypeTrueFalse
+			},
+			want: []string{"options[0].id", "options[1].id"},
+		},
+		{
+			name: "numeric answer",
+			modify: func(q *Question) {
+				q.Type = TypeNumeric
+				q.Options = nil
+				q.Numeric = &NumericAnswer{Value: 1, Tolerance: -1}
+			},
+			want: []string{"numeric.tolerance"},
+		},
+		{
+			name: "several fields",
+			modify: func(q *Question) {
+				q.Text = ""
+				q.Difficulty = MaxDifficulty + 1
+				q.Tags = []string{"europe", ""}
+			},
+			want: []string{"text", "difficulty", "tags[1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid()
+			tt.modify(&q)
+
+			err := q.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidQuestion) {
+				t.Fatalf("Validate() = %v, want ErrInvalidQuestion", err)
+			}
+
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) {
+				t.Fatalf("Validate() = %T, want *ValidationError", err)
+			}
+
+			got := make([]string, 0, len(vErr.Fields))
+			for _, f := range vErr.Fields {
+				got = append(got, f.Field)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package quiz
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+)
+
+const (
+	ModeSolo = "solo"
+
+	OutcomeCompleted = "completed"
+	OutcomeAbandoned = "abandoned"
+
+	// PointsPerQuestion is the base a fully correct answer scores, partially
+	// correct ones get a share of it. Scoring strategies build on it.
+	PointsPerQuestion = 100
+)
+
+// Modes lists the game modes, each can have a scoring strategy configured.
+func Modes() []string {
+	return []string{ModeSolo}
+}
+
+type Session struct {
+	ID                xid.ID        `bson:"_id"`
+	Version           xid.ID        `bson:"version"`
+	TelegramID        int64         `bson:"telegram_id"`
+	Mode              string        `bson:"mode"`
+	Deck              DeckRequest   `bson:"deck"`
+	Questions         []Question    `bson:"questions"`
+	Answers           []Answer      `bson:"answers"`
+	Score             int           `bson:"score"`
+	TimeLimit         time.Duration `bson:"time_limit"`
+	QuestionStartedAt time.Time     `bson:"question_started_at"`
+	StartedAt         time.Time     `bson:"started_at"`
+	FinishedAt        *time.Time    `bson:"finished_at"`
+	Outcome           string        `bson:"outcome,omitempty"`
+
+	// Scoring names the strategy answers are scored with, MaxPoints is the
+	// most they can score. Both are empty in sessions from before scoring
+	// strategies.
+	Scoring   string `bson:"scoring,omitempty"`
+	MaxPoints int    `bson:"max_points,omitempty"`
+}
+
+func (s Session) Finished() bool {
+	return s.FinishedAt != nil
+}
+
+func (s Session) MaxScore() int {
+	if s.MaxPoints > 0 {
+		return s.MaxPoints
+	}
+
+	return len(s.Questions) * PointsPerQuestion
+}
+
+// Streak counts the correct answers in a row up to the last one.
+func (s Session) Streak() int {
+	streak := 0
+
+	for i := len(s.Answers) - 1; i >= 0 && s.Answers[i].Correct; i-- {
+		streak++
+	}
+
+	return streak
+}
+
+// Current returns the question to answer next and its index.
+func (s Session) Current() (Question, int, bool) {
+	idx := len(s.Answers)
+	if s.Finished() || idx >= len(s.Questions) {
+		return Question{}, idx, false
+	}
+
+	return s.Questions[idx], idx, true
+}
+
+func (s Session) Deadline() time.Time {
+	return s.QuestionStartedAt.Add(s.TimeLimit)
+}
+
+// Response is what the player submitted: option IDs for choice questions or
+// a number for numeric ones. An empty response means the time ran out.
+type Response struct {
+	OptionIDs []string
+	Number    *float64
+}
+
+func (r Response) Empty() bool {
+	return len(r.OptionIDs) == 0 && r.Number == nil
+}
+
+type Answer struct {
+	QuestionID xid.ID        `bson:"question_id"`
+	OptionIDs  []string      `bson:"option_ids"`
+	Number     *float64      `bson:"number,omitempty"`
+	Credit     float64       `bson:"credit"`
+	Points     int           `bson:"points"`
+	Correct    bool          `bson:"correct"`
+	TimedOut   bool          `bson:"timed_out"`
+	Elapsed    time.Duration `bson:"elapsed"`
+	AnsweredAt time.Time     `bson:"answered_at"`
+	// Breakdown adds up to Points.
+	Breakdown Points `bson:"breakdown"`
+}
+
+type AnswerResult struct {
+	Answer   Answer
+	Question Question
+	Session  Session
+}
@@ -0,0 +1,150 @@
+package quiz
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	ScoringClassic = "classic"
+	ScoringTimed   = "timed"
+)
+
+// Points is the breakdown of the points an answer scored.
+type Points struct {
+	Base   int `bson:"base"`
+	Speed  int `bson:"speed"`
+	Streak int `bson:"streak"`
+}
+
+func (p Points) Total() int {
+	return p.Base + p.Speed + p.Streak
+}
+
+// ScoreInput is a graded answer with what strategies may weigh it by.
+type ScoreInput struct {
+	Question Question
+	// Answer has Credit, Correct, TimedOut and Elapsed, measured on the
+	// server, set.
+	Answer    Answer
+	TimeLimit time.Duration
+	// Streak counts correct answers in a row right before this one.
+	Streak int
+}
+
+// ScoringStrategy is a formula for the points of an answer. Modes use
+// different strategies, see Scoring.
+type ScoringStrategy interface {
+	Score(in ScoreInput) Points
+	// MaxScore is the most a perfect answer to q scores after a streak of
+	// the given length.
+	MaxScore(q Question, streak int) int
+}
+
+// Scoring has the strategies by name and the name each mode uses. Sessions
+// keep the name they started with, so changing a mode's strategy doesn't
+// change the score of games in progress.
+type Scoring struct {
+	Strategies map[string]ScoringStrategy
+	Modes      map[string]string
+}
+
+// ScoringStrategies returns the built-in strategies by name.
+func ScoringStrategies() map[string]ScoringStrategy {
+	return map[string]ScoringStrategy{
+		ScoringClassic: ClassicScoring{},
+		ScoringTimed: TimedScoring{
+			SpeedBonus:  50,
+			StreakBonus: 10,
+			MaxStreak:   5,
+		},
+	}
+}
+
+// strategy falls back to ClassicScoring for sessions from before strategies
+// existed and for names no longer configured.
+func (s Scoring) strategy(name string) ScoringStrategy {
+	if strategy, ok := s.Strategies[name]; ok {
+		return strategy
+	}
+
+	return ClassicScoring{}
+}
+
+// modeStrategy returns the name of the strategy of mode.
+func (s Scoring) modeStrategy(mode string) string {
+	if name, ok := s.Modes[mode]; ok {
+		return name
+	}
+
+	return ScoringClassic
+}
+
+// ClassicScoring awards the credit share of PointsPerQuestion, however long
+// the answer took.
+type ClassicScoring struct{}
+
+func (ClassicScoring) Score(in ScoreInput) Points {
+	return Points{Base: int(math.Round(in.Answer.Credit * PointsPerQuestion))}
+}
+
+func (ClassicScoring) MaxScore(Question, int) int {
+	return PointsPerQuestion
+}
+
+// TimedScoring scales PointsPerQuestion by difficulty, from 1x for trivial
+// to 2x for expert questions, and adds bonuses: up to SpeedBonus for the
+// time left, shared by credit like the base, and StreakBonus per correct
+// answer in a row before a correct one, counting up to MaxStreak.
+type TimedScoring struct {
+	SpeedBonus  int
+	StreakBonus int
+	MaxStreak   int
+}
+
+func (t TimedScoring) Score(in ScoreInput) Points {
+	a := in.Answer
+	if a.TimedOut || a.Credit <= 0 {
+		return Points{}
+	}
+
+	p := Points{Base: int(math.Round(a.Credit * PointsPerQuestion * difficultyFactor(in.Question.Difficulty)))}
+
+	if in.TimeLimit > 0 {
+		left := float64(in.TimeLimit-a.Elapsed) / float64(in.TimeLimit)
+		p.Speed = int(math.Round(a.Credit * float64(t.SpeedBonus) * math.Max(0, math.Min(1, left))))
+	}
+
+	if a.Correct {
+		p.Streak = t.StreakBonus * t.cappedStreak(in.Streak)
+	}
+
+	return p
+}
+
+func (t TimedScoring) MaxScore(q Question, streak int) int {
+	base := int(math.Round(PointsPerQuestion * difficultyFactor(q.Difficulty)))
+
+	return base + t.SpeedBonus + t.StreakBonus*t.cappedStreak(streak)
+}
+
+func (t TimedScoring) cappedStreak(streak int) int {
+	if streak > t.MaxStreak {
+		return t.MaxStreak
+	}
+
+	return streak
+}
+
+// difficultyFactor counts questions without a difficulty as trivial.
+func difficultyFactor(difficulty int) float64 {
+	if difficulty < MinDifficulty {
+		difficulty = MinDifficulty
+	}
+
+	if difficulty > MaxDifficulty {
+		difficulty = MaxDifficulty
+	}
+
+	return 1 + float64(difficulty-MinDifficulty)/float64(MaxDifficulty-MinDifficulty)
+}
@@ -0,0 +1,265 @@
+package quiz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassicScoringScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer Answer
+		want   Points
+	}{
+		{
+			name:   "correct",
+			answer: Answer{Credit: 1, Correct: true},
+			want:   Points{Base: 100},
+		},
+		{
+			name:   "partial credit",
+			answer: Answer{Credit: 0.5},
+			want:   Points{Base: 50},
+		},
+		{
+			name:   "partial credit rounds",
+			answer: Answer{Credit: 2.0 / 3},
+			want:   Points{Base: 67},
+		},
+		{
+			name:   "zero credit",
+			answer: Answer{},
+			want:   Points{},
+		},
+		{
+			name:   "timed out",
+			answer: Answer{TimedOut: true},
+			want:   Points{},
+		},
+		{
+			name:   "slow answers score the same",
+			answer: Answer{Credit: 1, Correct: true, Elapsed: time.Hour},
+			want:   Points{Base: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassicScoring{}.Score(ScoreInput{
+				Question:  Question{Difficulty: MaxDifficulty},
+				Answer:    tt.answer,
+				TimeLimit: 10 * time.Second,
+				Streak:    3,
+			})
+
+			if got != tt.want {
+				t.Errorf("Score() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimedScoringScore(t *testing.T) {
+	scoring := TimedScoring{SpeedBonus: 50, StreakBonus: 10, MaxStreak: 5}
+
+	tests := []struct {
+		name       string
+		difficulty int
+		answer     Answer
+		timeLimit  time.Duration
+		streak     int
+		want       Points
+	}{
+		{
+			name:       "instant correct answer to a trivial question",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true},
+			timeLimit:  10 * time.Second,
+			want:       Points{Base: 100, Speed: 50},
+		},
+		{
+			name:       "difficulty, time left and streak",
+			difficulty: 3,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 5 * time.Second},
+			timeLimit:  10 * time.Second,
+			streak:     2,
+			want:       Points{Base: 150, Speed: 25, Streak: 20},
+		},
+		{
+			name:       "timed out",
+			difficulty: MaxDifficulty,
+			answer:     Answer{Credit: 1, Correct: true, TimedOut: true},
+			timeLimit:  10 * time.Second,
+			streak:     2,
+			want:       Points{},
+		},
+		{
+			name:       "zero credit",
+			difficulty: MaxDifficulty,
+			answer:     Answer{Elapsed: time.Second},
+			timeLimit:  10 * time.Second,
+			streak:     2,
+			want:       Points{},
+		},
+		{
+			name:       "elapsed equals the time limit",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 10 * time.Second},
+			timeLimit:  10 * time.Second,
+			want:       Points{Base: 100},
+		},
+		{
+			name:       "elapsed over the time limit",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 12 * time.Second},
+			timeLimit:  10 * time.Second,
+			want:       Points{Base: 100},
+		},
+		{
+			name:       "no time limit",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true},
+			streak:     1,
+			want:       Points{Base: 100, Streak: 10},
+		},
+		{
+			name:       "streak at the cap",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 10 * time.Second},
+			timeLimit:  10 * time.Second,
+			streak:     5,
+			want:       Points{Base: 100, Streak: 50},
+		},
+		{
+			name:       "streak over the cap",
+			difficulty: MinDifficulty,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 10 * time.Second},
+			timeLimit:  10 * time.Second,
+			streak:     9,
+			want:       Points{Base: 100, Streak: 50},
+		},
+		{
+			name:       "partial multi-select credit",
+			difficulty: MaxDifficulty,
+			answer:     Answer{Credit: 0.5},
+			timeLimit:  10 * time.Second,
+			streak:     3,
+			want:       Points{Base: 100, Speed: 25},
+		},
+		{
+			name:       "difficulty below the minimum",
+			difficulty: MinDifficulty - 1,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 10 * time.Second},
+			timeLimit:  10 * time.Second,
+			want:       Points{Base: 100},
+		},
+		{
+			name:       "difficulty above the maximum",
+			difficulty: MaxDifficulty + 4,
+			answer:     Answer{Credit: 1, Correct: true, Elapsed: 10 * time.Second},
+			timeLimit:  10 * time.Second,
+			want:       Points{Base: 200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoring.Score(ScoreInput{
+				Question:  Question{Difficulty: tt.difficulty},
+				Answer:    tt.answer,
+				TimeLimit: tt.timeLimit,
+				Streak:    tt.streak,
+			})
+
+			if got != tt.want {
+				t.Errorf("Score() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxScore(t *testing.T) {
+	timed := TimedScoring{SpeedBonus: 50, StreakBonus: 10, MaxStreak: 5}
+
+	tests := []struct {
+		name       string
+		strategy   ScoringStrategy
+		difficulty int
+		streak     int
+		want       int
+	}{
+		{
+			name:       "classic",
+			strategy:   ClassicScoring{},
+			difficulty: MaxDifficulty,
+			streak:     4,
+			want:       100,
+		},
+		{
+			name:       "timed trivial without streak",
+			strategy:   timed,
+			difficulty: MinDifficulty,
+			want:       150,
+		},
+		{
+			name:       "timed expert with streak",
+			strategy:   timed,
+			difficulty: MaxDifficulty,
+			streak:     3,
+			want:       280,
+		},
+		{
+			name:       "timed streak over the cap",
+			strategy:   timed,
+			difficulty: MaxDifficulty,
+			streak:     9,
+			want:       300,
+		},
+		{
+			name:       "timed difficulty below the minimum",
+			strategy:   timed,
+			difficulty: 0,
+			want:       150,
+		},
+		{
+			name:       "timed difficulty above the maximum",
+			strategy:   timed,
+			difficulty: MaxDifficulty + 2,
+			want:       250,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.strategy.MaxScore(Question{Difficulty: tt.difficulty}, tt.streak)
+			if got != tt.want {
+				t.Errorf("MaxScore() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMaxScoreBoundsScore keeps MaxPoints of sessions an upper bound of
+// what a perfect game scores.
+func TestMaxScoreBoundsScore(t *testing.T) {
+	strategies := ScoringStrategies()
+
+	for name, strategy := range strategies {
+		for difficulty := MinDifficulty - 1; difficulty <= MaxDifficulty+1; difficulty++ {
+			for streak := 0; streak <= 7; streak++ {
+				q := Question{Difficulty: difficulty}
+				got := strategy.Score(ScoreInput{
+					Question:  q,
+					Answer:    Answer{Credit: 1, Correct: true},
+					TimeLimit: 10 * time.Second,
+					Streak:    streak,
+				})
+
+				if want := strategy.MaxScore(q, streak); got.Total() != want {
+					t.Errorf("%s: difficulty %d, streak %d: Score() = %d, MaxScore() = %d",
+						name, difficulty, streak, got.Total(), want)
+				}
+			}
+		}
+	}
+}
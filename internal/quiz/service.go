@@ -0,0 +1,272 @@
+package quiz
+
+import (
+	"00-go-base-tpl-sv/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+type Service interface {
+	// Start returns the active session of the player or starts a new one
+	// with a deck matching req, preferring questions the player has not seen
+	// recently.
+	Start(ctx context.Context, telegramID int64, req DeckRequest) (Session, error)
+	// Categories lists question categories available in language, or in any
+	// language when it is empty.
+	Categories(ctx context.Context, language string) ([]Category, error)
+	// Current returns the active session of the player or ErrNotFound.
+	Current(ctx context.Context, telegramID int64) (Session, error)
+	// Answer submits the response for the question at questionIndex, empty
+	// when the player ran out of time. Answers after the deadline count as
+	// timed out.
+	Answer(ctx context.Context, telegramID int64, id xid.ID, questionIndex int, r Response) (AnswerResult, error)
+}
+
+// Events tells other services about quiz sessions. Sessions are saved by the
+// time it is called, so it reports its own failures.
+type Events interface {
+	SessionFinished(ctx context.Context, s Session)
+}
+
+type service struct {
+	questions QuestionStorage
+	sessions  SessionStorage
+	metrics   *metrics.Quiz
+	events    Events
+	scoring   Scoring
+
+	questionsPerSession int
+	timeLimit           time.Duration
+	recentWindow        time.Duration
+}
+
+func NewService(
+	questions QuestionStorage,
+	sessions SessionStorage,
+	metrics *metrics.Quiz,
+	events Events,
+	scoring Scoring,
+	questionsPerSession int,
+	timeLimit time.Duration,
+	recentWindow time.Duration,
+) Service {
+	return &service{
+		questions:           questions,
+		sessions:            sessions,
+		metrics:             metrics,
+		events:              events,
+		scoring:             scoring,
+		questionsPerSession: questionsPerSession,
+		timeLimit:           timeLimit,
+		recentWindow:        recentWindow,
+	}
+}
+
+func (c *service) Start(ctx context.Context, telegramID int64, req DeckRequest) (Session, error) {
+	if err := req.Validate(); err != nil {
+		return Session{}, err
+	}
+
+	s, err := c.Current(ctx, telegramID)
+	if err == nil {
+		return s, nil
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		return Session{}, err
+	}
+
+	questions, err := c.deck(ctx, telegramID, req)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if len(questions) == 0 {
+		return Session{}, ErrNoQuestions
+	}
+
+	scoring := c.scoring.modeStrategy(ModeSolo)
+	strategy := c.scoring.strategy(scoring)
+
+	// the best game answers every question correctly, so the streak before
+	// a question is its index
+	maxPoints := 0
+	for i, q := range questions {
+		maxPoints += strategy.MaxScore(q, i)
+	}
+
+	now := time.Now().UTC()
+	s = Session{
+		ID:                xid.New(),
+		Version:           xid.New(),
+		TelegramID:        telegramID,
+		Mode:              ModeSolo,
+		Deck:              req,
+		Questions:         questions,
+		Answers:           []Answer{},
+		TimeLimit:         c.timeLimit,
+		QuestionStartedAt: now,
+		StartedAt:         now,
+		Scoring:           scoring,
+		MaxPoints:         maxPoints,
+	}
+
+	s, err = c.sessions.Insert(ctx, s)
+	if errors.Is(err, ErrSessionActive) {
+		// a concurrent Start of the player won
+		return c.sessions.GetActive(ctx, telegramID)
+	}
+
+	if err != nil {
+		return Session{}, fmt.Errorf("insert quiz session: %w", err)
+	}
+
+	c.metrics.SessionStarted(s.Mode)
+
+	return s, nil
+}
+
+func (c *service) deck(ctx context.Context, telegramID int64, req DeckRequest) ([]Question, error) {
+	candidates, err := c.questions.Sample(ctx, req, c.questionsPerSession*deckPoolFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := c.sessions.RecentQuestionIDs(
+		ctx,
+		telegramID,
+		time.Now().UTC().Add(-c.recentWindow),
+		recentSessionsLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("recent questions: %w", err)
+	}
+
+	seen := make(map[xid.ID]bool, len(recent))
+	for _, id := range recent {
+		seen[id] = true
+	}
+
+	return pickDeck(candidates, req, seen, c.questionsPerSession), nil
+}
+
+func (c *service) Categories(ctx context.Context, language string) ([]Category, error) {
+	return c.questions.Categories(ctx, language)
+}
+
+// Current also abandons a session the player left: one whose current
+// question deadline passed more than a time limit ago.
+func (c *service) Current(ctx context.Context, telegramID int64) (Session, error) {
+	s, err := c.sessions.GetActive(ctx, telegramID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now().UTC()
+	if now.Before(s.Deadline().Add(s.TimeLimit)) {
+		return s, nil
+	}
+
+	abandoned := s
+	abandoned.Version = xid.New()
+	abandoned.FinishedAt = &now
+	abandoned.Outcome = OutcomeAbandoned
+
+	// only the request which abandoned the session counts it
+	_, err = c.sessions.Replace(ctx, s, abandoned)
+
+	switch {
+	case err == nil:
+		c.metrics.SessionFinished(s.Mode, OutcomeAbandoned)
+		c.events.SessionFinished(ctx, abandoned)
+	case !errors.Is(err, ErrVersionMismatch):
+		return Session{}, fmt.Errorf("abandon quiz session: %w", err)
+	}
+
+	return Session{}, ErrNotFound
+}
+
+func (c *service) Answer(
+	ctx context.Context,
+	telegramID int64,
+	id xid.ID,
+	questionIndex int,
+	r Response,
+) (AnswerResult, error) {
+	oldS, err := c.sessions.GetByID(ctx, id)
+	if err != nil {
+		return AnswerResult{}, err
+	}
+
+	// other players' sessions are reported as missing, not forbidden
+	if oldS.TelegramID != telegramID {
+		return AnswerResult{}, ErrNotFound
+	}
+
+	q, idx, ok := oldS.Current()
+	if !ok {
+		return AnswerResult{}, ErrFinished
+	}
+
+	if questionIndex != idx {
+		return AnswerResult{}, ErrQuestionMismatch
+	}
+
+	credit, err := q.Grade(r)
+	if err != nil {
+		return AnswerResult{}, err
+	}
+
+	now := time.Now().UTC()
+	answer := Answer{
+		QuestionID: q.ID,
+		OptionIDs:  r.OptionIDs,
+		Number:     r.Number,
+		TimedOut:   r.Empty() || now.After(oldS.Deadline()),
+		Elapsed:    now.Sub(oldS.QuestionStartedAt),
+		AnsweredAt: now,
+	}
+
+	if !answer.TimedOut {
+		answer.Credit = credit
+		answer.Correct = credit == 1
+	}
+
+	answer.Breakdown = c.scoring.strategy(oldS.Scoring).Score(ScoreInput{
+		Question:  q,
+		Answer:    answer,
+		TimeLimit: oldS.TimeLimit,
+		Streak:    oldS.Streak(),
+	})
+	answer.Points = answer.Breakdown.Total()
+
+	newS := oldS
+	newS.Version = xid.New()
+	newS.Answers = append(append([]Answer{}, oldS.Answers...), answer)
+	newS.QuestionStartedAt = now
+
+	newS.Score += answer.Points
+
+	if len(newS.Answers) == len(newS.Questions) {
+		newS.FinishedAt = &now
+		newS.Outcome = OutcomeCompleted
+	}
+
+	newS, err = c.sessions.Replace(ctx, oldS, newS)
+	if err != nil {
+		return AnswerResult{}, fmt.Errorf("replace quiz session: %w", err)
+	}
+
+	c.metrics.Answer(string(q.Type), q.Difficulty, answer.Correct)
+
+	if newS.Finished() {
+		c.metrics.SessionFinished(newS.Mode, newS.Outcome)
+		c.events.SessionFinished(ctx, newS)
+	}
+
+	return AnswerResult{Answer: answer, Question: q, Session: newS}, nil
+}
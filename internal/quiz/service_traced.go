@@ -0,0 +1,292 @@
+package quiz
+
+import (
+	"00-go-base-tpl-sv/internal/tracing"
+	"context"
+
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+func NewTracedService(next Service, tracer trace.Tracer) Service {
+	return &tracedService{next: next, tracer: tracer}
+}
+
+func (s *tracedService) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "quiz.Service/"+name, trace.WithAttributes(attrs...))
+}
+
+func userAttr(telegramID int64) attribute.KeyValue {
+	return attribute.Int64("telegram.user_id", telegramID)
+}
+
+func (s *tracedService) Start(ctx context.Context, telegramID int64, req DeckRequest) (session Session, err error) {
+	ctx, span := s.start(
+		ctx,
+		"Start",
+		userAttr(telegramID),
+		attribute.String("quiz.deck.category", req.Category),
+		attribute.Int("quiz.deck.difficulty", req.Difficulty),
+		attribute.String("quiz.deck.language", req.Language),
+	)
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Start(ctx, telegramID, req)
+}
+
+func (s *tracedService) Categories(ctx context.Context, language string) (categories []Category, err error) {
+	ctx, span := s.start(ctx, "Categories", attribute.String("quiz.language", language))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Categories(ctx, language)
+}
+
+func (s *tracedService) Current(ctx context.Context, telegramID int64) (session Session, err error) {
+	ctx, span := s.start(ctx, "Current", userAttr(telegramID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Current(ctx, telegramID)
+}
+
+func (s *tracedService) Answer(
+	ctx context.Context,
+	telegramID int64,
+	id xid.ID,
+	questionIndex int,
+	r Response,
+) (res AnswerResult, err error) {
+	ctx, span := s.start(
+		ctx,
+		"Answer",
+		userAttr(telegramID),
+		attribute.String("quiz.session_id", id.String()),
+		attribute.Int("quiz.question_index", questionIndex),
+	)
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Answer(ctx, telegramID, id, questionIndex, r)
+}
+
+type tracedAuthoringService struct {
+	next   AuthoringService
+	tracer trace.Tracer
+}
+
+func NewTracedAuthoringService(next AuthoringService, tracer trace.Tracer) AuthoringService {
+	return &tracedAuthoringService{next: next, tracer: tracer}
+}
+
+func (s *tracedAuthoringService) start(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "quiz.AuthoringService/"+name, trace.WithAttributes(attrs...))
+}
+
+func questionAttr(id xid.ID) attribute.KeyValue {
+	return attribute.String("quiz.question_id", id.String())
+}
+
+func localeAttr(locale string) attribute.KeyValue {
+	return attribute.String("quiz.locale", locale)
+}
+
+func (s *tracedAuthoringService) Create(ctx context.Context, authorID int64, q Question) (r Revision, err error) {
+	ctx, span := s.start(ctx, "Create", userAttr(authorID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Create(ctx, authorID, q)
+}
+
+func (s *tracedAuthoringService) Edit(
+	ctx context.Context,
+	authorID int64,
+	questionID xid.ID,
+	q Question,
+) (r Revision, err error) {
+	ctx, span := s.start(ctx, "Edit", userAttr(authorID), questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Edit(ctx, authorID, questionID, q)
+}
+
+func (s *tracedAuthoringService) Submit(ctx context.Context, authorID int64, questionID xid.ID) (r Revision, err error) {
+	ctx, span := s.start(ctx, "Submit", userAttr(authorID), questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Submit(ctx, authorID, questionID)
+}
+
+func (s *tracedAuthoringService) Approve(ctx context.Context, reviewerID int64, questionID xid.ID) (r Revision, err error) {
+	ctx, span := s.start(ctx, "Approve", userAttr(reviewerID), questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Approve(ctx, reviewerID, questionID)
+}
+
+func (s *tracedAuthoringService) Reject(
+	ctx context.Context,
+	reviewerID int64,
+	questionID xid.ID,
+	note string,
+) (r Revision, err error) {
+	ctx, span := s.start(ctx, "Reject", userAttr(reviewerID), questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Reject(ctx, reviewerID, questionID, note)
+}
+
+func (s *tracedAuthoringService) Retire(ctx context.Context, questionID xid.ID) (q Question, err error) {
+	ctx, span := s.start(ctx, "Retire", questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Retire(ctx, questionID)
+}
+
+func (s *tracedAuthoringService) Discard(ctx context.Context, questionID xid.ID) (err error) {
+	ctx, span := s.start(ctx, "Discard", questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Discard(ctx, questionID)
+}
+
+func (s *tracedAuthoringService) Revisions(ctx context.Context, questionID xid.ID) (rs []Revision, err error) {
+	ctx, span := s.start(ctx, "Revisions", questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Revisions(ctx, questionID)
+}
+
+func (s *tracedAuthoringService) ListByStatus(ctx context.Context, status RevisionStatus) (rs []Revision, err error) {
+	ctx, span := s.start(ctx, "ListByStatus", attribute.String("quiz.revision_status", string(status)))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.ListByStatus(ctx, status)
+}
+
+func (s *tracedAuthoringService) FindByExternalID(ctx context.Context, externalID string) (id xid.ID, err error) {
+	ctx, span := s.start(ctx, "FindByExternalID", attribute.String("quiz.question_external_id", externalID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.FindByExternalID(ctx, externalID)
+}
+
+func (s *tracedAuthoringService) List(ctx context.Context, filter QuestionFilter) (qs []Question, err error) {
+	ctx, span := s.start(
+		ctx,
+		"List",
+		attribute.String("quiz.question_status", string(filter.Status)),
+		attribute.String("quiz.category", filter.Category),
+		attribute.String("quiz.language", filter.Language),
+	)
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.List(ctx, filter)
+}
+
+type tracedCommunityService struct {
+	next   CommunityService
+	tracer trace.Tracer
+}
+
+func NewTracedCommunityService(next CommunityService, tracer trace.Tracer) CommunityService {
+	return &tracedCommunityService{next: next, tracer: tracer}
+}
+
+func (s *tracedCommunityService) start(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "quiz.CommunityService/"+name, trace.WithAttributes(attrs...))
+}
+
+func (s *tracedCommunityService) Submit(ctx context.Context, telegramID int64, q Question) (c CommunityQuestion, err error) {
+	ctx, span := s.start(ctx, "Submit", userAttr(telegramID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Submit(ctx, telegramID, q)
+}
+
+func (s *tracedCommunityService) Queue(ctx context.Context, telegramID int64) (cs []CommunityQuestion, err error) {
+	ctx, span := s.start(ctx, "Queue", userAttr(telegramID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Queue(ctx, telegramID)
+}
+
+func (s *tracedCommunityService) Vote(
+	ctx context.Context,
+	telegramID int64,
+	id xid.ID,
+	vote int,
+) (c CommunityQuestion, err error) {
+	ctx, span := s.start(ctx, "Vote", userAttr(telegramID), questionAttr(id), attribute.Int("quiz.vote", vote))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Vote(ctx, telegramID, id, vote)
+}
+
+func (s *tracedCommunityService) Report(
+	ctx context.Context,
+	telegramID int64,
+	id xid.ID,
+	reason string,
+) (c CommunityQuestion, err error) {
+	ctx, span := s.start(ctx, "Report", userAttr(telegramID), questionAttr(id))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Report(ctx, telegramID, id, reason)
+}
+
+type tracedTranslationService struct {
+	next   TranslationService
+	tracer trace.Tracer
+}
+
+func NewTracedTranslationService(next TranslationService, tracer trace.Tracer) TranslationService {
+	return &tracedTranslationService{next: next, tracer: tracer}
+}
+
+func (s *tracedTranslationService) start(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "quiz.TranslationService/"+name, trace.WithAttributes(attrs...))
+}
+
+func (s *tracedTranslationService) Put(ctx context.Context, authorID int64, t Translation) (tr Translation, err error) {
+	ctx, span := s.start(ctx, "Put", userAttr(authorID), questionAttr(t.QuestionID), localeAttr(t.Locale))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Put(ctx, authorID, t)
+}
+
+func (s *tracedTranslationService) Delete(ctx context.Context, questionID xid.ID, locale string) (err error) {
+	ctx, span := s.start(ctx, "Delete", questionAttr(questionID), localeAttr(locale))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Delete(ctx, questionID, locale)
+}
+
+func (s *tracedTranslationService) List(ctx context.Context, questionID xid.ID) (ts []Translation, err error) {
+	ctx, span := s.start(ctx, "List", questionAttr(questionID))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.List(ctx, questionID)
+}
+
+func (s *tracedTranslationService) Localize(ctx context.Context, q Question, locales []string) (lq Question, err error) {
+	ctx, span := s.start(ctx, "Localize", questionAttr(q.ID), attribute.StringSlice("quiz.locales", locales))
+	defer func() { tracing.Finish(span, err) }()
+
+	return s.next.Localize(ctx, q, locales)
+}
@@ -0,0 +1,708 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuestionStorage interface {
+	// Sample returns up to n random questions matching req, with neighbouring
+	// difficulty levels when req has one.
+	Sample(ctx context.Context, req DeckRequest, n int) ([]Question, error)
+	Categories(ctx context.Context, language string) ([]Category, error)
+	Get(ctx context.Context, id xid.ID) (Question, error)
+	// Publish creates or replaces the question, making it playable.
+	Publish(ctx context.Context, q Question) error
+	SetStatus(ctx context.Context, id xid.ID, status QuestionStatus) (Question, error)
+	List(ctx context.Context, filter QuestionFilter) ([]Question, error)
+}
+
+type RevisionStorage interface {
+	Insert(ctx context.Context, r Revision) (Revision, error)
+	Replace(ctx context.Context, oldR, newR Revision) (Revision, error)
+	// Delete removes r unless it was changed meanwhile.
+	Delete(ctx context.Context, r Revision) error
+	GetLatest(ctx context.Context, questionID xid.ID) (Revision, error)
+	GetLatestByExternalID(ctx context.Context, externalID string) (Revision, error)
+	// ExternalIDTaken reports whether a revision of a question other than
+	// questionID has externalID.
+	ExternalIDTaken(ctx context.Context, externalID string, questionID xid.ID) (bool, error)
+	ListByQuestion(ctx context.Context, questionID xid.ID) ([]Revision, error)
+	ListByStatus(ctx context.Context, status RevisionStatus) ([]Revision, error)
+}
+
+type CommunityStorage interface {
+	Insert(ctx context.Context, c CommunityQuestion) (CommunityQuestion, error)
+	Get(ctx context.Context, id xid.ID) (CommunityQuestion, error)
+	Delete(ctx context.Context, id xid.ID) error
+	// ListPending skips questions of excludeAuthor, oldest first.
+	ListPending(ctx context.Context, excludeAuthor int64, limit int) ([]CommunityQuestion, error)
+	CountByAuthorSince(ctx context.Context, authorID int64, since time.Time) (int, error)
+	// Vote records the vote of the player and updates the tallies by the
+	// difference to their previous vote.
+	Vote(ctx context.Context, id xid.ID, telegramID int64, vote int) (CommunityQuestion, error)
+	Report(ctx context.Context, id xid.ID, telegramID int64, reason string) (CommunityQuestion, error)
+	// SetStatus reports false when the status isn't from any more.
+	SetStatus(ctx context.Context, id xid.ID, from, to CommunityStatus) (CommunityQuestion, bool, error)
+}
+
+type TranslationStorage interface {
+	// Upsert replaces the translation of the question into the locale, keeping
+	// its ID, or inserts t.
+	Upsert(ctx context.Context, t Translation) (Translation, error)
+	Delete(ctx context.Context, questionID xid.ID, locale string) error
+	ListByQuestion(ctx context.Context, questionID xid.ID) ([]Translation, error)
+}
+
+type SessionStorage interface {
+	Insert(ctx context.Context, s Session) (Session, error)
+	Replace(ctx context.Context, oldS, newS Session) (Session, error)
+	GetByID(ctx context.Context, id xid.ID) (Session, error)
+	GetActive(ctx context.Context, telegramID int64) (Session, error)
+	// RecentQuestionIDs lists questions of the player's last sessions
+	// started after since.
+	RecentQuestionIDs(ctx context.Context, telegramID int64, since time.Time, sessions int) ([]xid.ID, error)
+}
+
+const (
+	revisionListLimit = 200
+	maxListQuestions  = 10000
+)
+
+type QuestionStorageMongo struct {
+	collection *mongo.Collection
+}
+
+func NewQuestionStorageMongo(collection *mongo.Collection) *QuestionStorageMongo {
+	return &QuestionStorageMongo{collection: collection}
+}
+
+func (s *QuestionStorageMongo) Sample(ctx context.Context, req DeckRequest, n int) ([]Question, error) {
+	match := bson.M{"status": QuestionPublished}
+
+	if req.Category != "" {
+		match["category"] = req.Category
+	}
+
+	if req.Language != "" {
+		match["language"] = req.Language
+	}
+
+	if req.Difficulty != 0 {
+		match["difficulty"] = bson.M{"$gte": req.Difficulty - 1, "$lte": req.Difficulty + 1}
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sample", Value: bson.M{"size": n}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sample questions: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	questions := make([]Question, 0, n)
+
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return questions, nil
+}
+
+func (s *QuestionStorageMongo) Categories(ctx context.Context, language string) ([]Category, error) {
+	match := bson.M{"status": QuestionPublished}
+	if language != "" {
+		match["language"] = language
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$category", "questions": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("group categories: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	categories := make([]Category, 0)
+
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (s *QuestionStorageMongo) Get(ctx context.Context, id xid.ID) (Question, error) {
+	var q Question
+
+	if err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&q); err != nil {
+		return Question{}, convertQuestionErr(err)
+	}
+
+	return q, nil
+}
+
+func (s *QuestionStorageMongo) Publish(ctx context.Context, q Question) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": q.ID}, q, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("replace question: %w", err)
+	}
+
+	return nil
+}
+
+func (s *QuestionStorageMongo) SetStatus(ctx context.Context, id xid.ID, status QuestionStatus) (Question, error) {
+	var q Question
+
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": status}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&q)
+	if err != nil {
+		return Question{}, convertQuestionErr(err)
+	}
+
+	return q, nil
+}
+
+// List returns questions sorted by category and ID, up to maxListQuestions.
+func (s *QuestionStorageMongo) List(ctx context.Context, filter QuestionFilter) ([]Question, error) {
+	match := bson.M{}
+
+	if filter.Status != "" {
+		match["status"] = filter.Status
+	}
+
+	if filter.Category != "" {
+		match["category"] = filter.Category
+	}
+
+	if filter.Language != "" {
+		match["language"] = filter.Language
+	}
+
+	cursor, err := s.collection.Find(
+		ctx,
+		match,
+		options.Find().
+			SetSort(bson.D{{Key: "category", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(maxListQuestions),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find questions: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	questions := make([]Question, 0)
+
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return questions, nil
+}
+
+type RevisionStorageMongo struct {
+	collection *mongo.Collection
+}
+
+func NewRevisionStorageMongo(collection *mongo.Collection) *RevisionStorageMongo {
+	return &RevisionStorageMongo{collection: collection}
+}
+
+// Insert relies on the unique (question_id, number) index, so two editors
+// can't both start the next revision.
+func (s *RevisionStorageMongo) Insert(ctx context.Context, r Revision) (Revision, error) {
+	_, err := s.collection.InsertOne(ctx, r)
+	if mongo.IsDuplicateKeyError(err) {
+		return Revision{}, ErrRevisionConflict
+	}
+
+	if err != nil {
+		return Revision{}, fmt.Errorf("insert question revision: %w", err)
+	}
+
+	return r, nil
+}
+
+func (s *RevisionStorageMongo) Replace(ctx context.Context, oldR, newR Revision) (Revision, error) {
+	res, err := s.collection.ReplaceOne(ctx, bson.M{"_id": oldR.ID, "version": oldR.Version}, newR)
+	if err != nil {
+		return Revision{}, fmt.Errorf("replace question revision: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return Revision{}, ErrRevisionConflict
+	}
+
+	return newR, nil
+}
+
+func (s *RevisionStorageMongo) Delete(ctx context.Context, r Revision) error {
+	res, err := s.collection.DeleteOne(ctx, bson.M{"_id": r.ID, "version": r.Version})
+	if err != nil {
+		return fmt.Errorf("delete question revision: %w", err)
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrRevisionConflict
+	}
+
+	return nil
+}
+
+func (s *RevisionStorageMongo) GetLatest(ctx context.Context, questionID xid.ID) (Revision, error) {
+	var r Revision
+
+	err := s.collection.FindOne(
+		ctx,
+		bson.M{"question_id": questionID},
+		options.FindOne().SetSort(bson.M{"number": -1}),
+	).Decode(&r)
+	if err != nil {
+		return Revision{}, convertQuestionErr(err)
+	}
+
+	return r, nil
+}
+
+func (s *RevisionStorageMongo) GetLatestByExternalID(ctx context.Context, externalID string) (Revision, error) {
+	var r Revision
+
+	err := s.collection.FindOne(
+		ctx,
+		bson.M{"question.external_id": externalID},
+		options.FindOne().SetSort(bson.M{"number": -1}),
+	).Decode(&r)
+	if err != nil {
+		return Revision{}, convertQuestionErr(err)
+	}
+
+	return r, nil
+}
+
+func (s *RevisionStorageMongo) ExternalIDTaken(ctx context.Context, externalID string, questionID xid.ID) (bool, error) {
+	n, err := s.collection.CountDocuments(
+		ctx,
+		bson.M{"question.external_id": externalID, "question_id": bson.M{"$ne": questionID}},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, fmt.Errorf("count revisions by external id: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+func (s *RevisionStorageMongo) ListByQuestion(ctx context.Context, questionID xid.ID) ([]Revision, error) {
+	revisions, err := s.find(ctx, bson.M{"question_id": questionID}, options.Find().SetSort(bson.M{"number": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, ErrQuestionNotFound
+	}
+
+	return revisions, nil
+}
+
+// ListByStatus returns the oldest revisions first, a review queue is worked
+// through in order of submission.
+func (s *RevisionStorageMongo) ListByStatus(ctx context.Context, status RevisionStatus) ([]Revision, error) {
+	return s.find(ctx, bson.M{"status": status}, options.Find().SetSort(bson.M{"updated_at": 1}).SetLimit(revisionListLimit))
+}
+
+func (s *RevisionStorageMongo) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]Revision, error) {
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find question revisions: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	revisions := make([]Revision, 0)
+
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// CommunityStorageMongo keeps a vote document per question and player in
+// votes, unique by the two, next to the tallies in questions.
+type CommunityStorageMongo struct {
+	questions *mongo.Collection
+	votes     *mongo.Collection
+}
+
+func NewCommunityStorageMongo(questions, votes *mongo.Collection) *CommunityStorageMongo {
+	return &CommunityStorageMongo{questions: questions, votes: votes}
+}
+
+func (s *CommunityStorageMongo) Insert(ctx context.Context, c CommunityQuestion) (CommunityQuestion, error) {
+	if _, err := s.questions.InsertOne(ctx, c); err != nil {
+		return CommunityQuestion{}, fmt.Errorf("insert community question: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *CommunityStorageMongo) Get(ctx context.Context, id xid.ID) (CommunityQuestion, error) {
+	var c CommunityQuestion
+
+	if err := s.questions.FindOne(ctx, bson.M{"_id": id}).Decode(&c); err != nil {
+		return CommunityQuestion{}, convertQuestionErr(err)
+	}
+
+	return c, nil
+}
+
+func (s *CommunityStorageMongo) Delete(ctx context.Context, id xid.ID) error {
+	if _, err := s.questions.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("delete community question: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CommunityStorageMongo) ListPending(ctx context.Context, excludeAuthor int64, limit int) ([]CommunityQuestion, error) {
+	cursor, err := s.questions.Find(
+		ctx,
+		bson.M{"status": CommunityPending, "author_id": bson.M{"$ne": excludeAuthor}},
+		options.Find().SetSort(bson.M{"created_at": 1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find community questions: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	questions := make([]CommunityQuestion, 0)
+
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return questions, nil
+}
+
+func (s *CommunityStorageMongo) CountByAuthorSince(ctx context.Context, authorID int64, since time.Time) (int, error) {
+	n, err := s.questions.CountDocuments(ctx, bson.M{"author_id": authorID, "created_at": bson.M{"$gte": since}})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *CommunityStorageMongo) Vote(ctx context.Context, id xid.ID, telegramID int64, vote int) (CommunityQuestion, error) {
+	var prev struct {
+		Vote int `bson:"vote"`
+	}
+
+	err := s.votes.FindOneAndUpdate(
+		ctx,
+		bson.M{"question_id": id, "telegram_id": telegramID},
+		bson.M{"$set": bson.M{"vote": vote, "voted_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&prev)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return CommunityQuestion{}, fmt.Errorf("upsert vote: %w", err)
+	}
+
+	inc := bson.M{}
+
+	if prev.Vote != vote {
+		inc[voteField(vote)] = 1
+
+		if prev.Vote != 0 {
+			inc[voteField(prev.Vote)] = -1
+		}
+	}
+
+	return s.update(ctx, id, inc)
+}
+
+// Report relies on the unique vote index: a second report doesn't match the
+// filter and its upsert collides with the existing document.
+func (s *CommunityStorageMongo) Report(ctx context.Context, id xid.ID, telegramID int64, reason string) (CommunityQuestion, error) {
+	_, err := s.votes.UpdateOne(
+		ctx,
+		bson.M{"question_id": id, "telegram_id": telegramID, "reported_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"reported_at": time.Now().UTC(), "report_reason": reason}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return CommunityQuestion{}, ErrAlreadyReported
+	}
+
+	if err != nil {
+		return CommunityQuestion{}, fmt.Errorf("upsert report: %w", err)
+	}
+
+	return s.update(ctx, id, bson.M{"reports": 1})
+}
+
+func (s *CommunityStorageMongo) SetStatus(
+	ctx context.Context,
+	id xid.ID,
+	from, to CommunityStatus,
+) (CommunityQuestion, bool, error) {
+	var c CommunityQuestion
+
+	err := s.questions.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updated_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&c)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return CommunityQuestion{}, false, nil
+	}
+
+	if err != nil {
+		return CommunityQuestion{}, false, fmt.Errorf("update community question status: %w", err)
+	}
+
+	return c, true, nil
+}
+
+func (s *CommunityStorageMongo) update(ctx context.Context, id xid.ID, inc bson.M) (CommunityQuestion, error) {
+	update := bson.M{"$set": bson.M{"updated_at": time.Now().UTC()}}
+	if len(inc) > 0 {
+		update["$inc"] = inc
+	}
+
+	var c CommunityQuestion
+
+	err := s.questions.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&c)
+	if err != nil {
+		return CommunityQuestion{}, convertQuestionErr(err)
+	}
+
+	return c, nil
+}
+
+func voteField(vote int) string {
+	if vote == VoteUp {
+		return "upvotes"
+	}
+
+	return "downvotes"
+}
+
+func convertQuestionErr(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrQuestionNotFound
+	}
+
+	return err
+}
+
+type TranslationStorageMongo struct {
+	collection *mongo.Collection
+}
+
+func NewTranslationStorageMongo(collection *mongo.Collection) *TranslationStorageMongo {
+	return &TranslationStorageMongo{collection: collection}
+}
+
+func (s *TranslationStorageMongo) Upsert(ctx context.Context, t Translation) (Translation, error) {
+	var saved Translation
+
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"question_id": t.QuestionID, "locale": t.Locale},
+		bson.M{
+			"$set": bson.M{
+				"revision":   t.Revision,
+				"text":       t.Text,
+				"options":    t.Options,
+				"media_alt":  t.MediaAlt,
+				"unit":       t.Unit,
+				"author_id":  t.AuthorID,
+				"updated_at": t.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{"_id": t.ID},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		return Translation{}, fmt.Errorf("upsert translation: %w", err)
+	}
+
+	return saved, nil
+}
+
+func (s *TranslationStorageMongo) Delete(ctx context.Context, questionID xid.ID, locale string) error {
+	res, err := s.collection.DeleteOne(ctx, bson.M{"question_id": questionID, "locale": locale})
+	if err != nil {
+		return fmt.Errorf("delete translation: %w", err)
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrTranslationNotFound
+	}
+
+	return nil
+}
+
+func (s *TranslationStorageMongo) ListByQuestion(ctx context.Context, questionID xid.ID) ([]Translation, error) {
+	cursor, err := s.collection.Find(
+		ctx,
+		bson.M{"question_id": questionID},
+		options.Find().SetSort(bson.M{"locale": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find translations: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	translations := make([]Translation, 0)
+
+	if err := cursor.All(ctx, &translations); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	return translations, nil
+}
+
+type SessionStorageMongo struct {
+	collection *mongo.Collection
+}
+
+func NewSessionStorageMongo(collection *mongo.Collection) *SessionStorageMongo {
+	return &SessionStorageMongo{collection: collection}
+}
+
+// Insert relies on the unique partial index on active sessions, so a player
+// can't have two of them.
+func (s *SessionStorageMongo) Insert(ctx context.Context, session Session) (Session, error) {
+	_, err := s.collection.InsertOne(ctx, session)
+	if mongo.IsDuplicateKeyError(err) {
+		return Session{}, ErrSessionActive
+	}
+
+	if err != nil {
+		return Session{}, s.convertErr(err)
+	}
+
+	return session, nil
+}
+
+func (s *SessionStorageMongo) Replace(ctx context.Context, oldS, newS Session) (Session, error) {
+	res, err := s.collection.ReplaceOne(
+		ctx,
+		bson.M{
+			"_id":     oldS.ID,
+			"version": oldS.Version,
+		},
+		newS,
+	)
+	if err != nil {
+		return Session{}, s.convertErr(err)
+	}
+
+	if res.MatchedCount == 0 {
+		return Session{}, ErrVersionMismatch
+	}
+
+	return newS, nil
+}
+
+func (s *SessionStorageMongo) GetByID(ctx context.Context, id xid.ID) (Session, error) {
+	var session Session
+
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		return Session{}, s.convertErr(err)
+	}
+
+	return session, nil
+}
+
+func (s *SessionStorageMongo) GetActive(ctx context.Context, telegramID int64) (Session, error) {
+	var session Session
+
+	err := s.collection.FindOne(ctx, bson.M{"telegram_id": telegramID, "finished_at": nil}).Decode(&session)
+	if err != nil {
+		return Session{}, s.convertErr(err)
+	}
+
+	return session, nil
+}
+
+func (s *SessionStorageMongo) RecentQuestionIDs(
+	ctx context.Context,
+	telegramID int64,
+	since time.Time,
+	sessions int,
+) ([]xid.ID, error) {
+	cursor, err := s.collection.Find(
+		ctx,
+		bson.M{"telegram_id": telegramID, "started_at": bson.M{"$gte": since}},
+		options.Find().
+			SetSort(bson.M{"started_at": -1}).
+			SetLimit(int64(sessions)).
+			SetProjection(bson.M{"questions._id": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find recent sessions: %w", err)
+	}
+
+	defer cursor.Close(ctx) // nolint
+
+	var docs []struct {
+		Questions []struct {
+			ID xid.ID `bson:"_id"`
+		} `bson:"questions"`
+	}
+
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("cursor convert all: %w", err)
+	}
+
+	ids := make([]xid.ID, 0, len(docs))
+	for _, d := range docs {
+		for _, q := range d.Questions {
+			ids = append(ids, q.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *SessionStorageMongo) convertErr(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,197 @@
+package quiz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+const (
+	maxTranslationText   = 1024
+	maxTranslationOption = 256
+)
+
+// Translation is a question in another locale. Options are matched by ID,
+// those left out keep the text of the question.
+type Translation struct {
+	ID         xid.ID `bson:"_id"`
+	QuestionID xid.ID `bson:"question_id"`
+	Locale     string `bson:"locale"`
+	// Revision is the question revision translated, an older one than the
+	// question's means the translation is outdated.
+	Revision int               `bson:"revision"`
+	Text     string            `bson:"text"`
+	Options  map[string]string `bson:"options,omitempty"`
+	MediaAlt string            `bson:"media_alt,omitempty"`
+	Unit     string            `bson:"unit,omitempty"`
+
+	AuthorID  int64     `bson:"author_id"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Validate checks the translation fits q, reporting broken rules in a
+// *ValidationError.
+func (t Translation) Validate(q Question) error {
+	var v fieldErrors
+
+	if len(t.Locale) != 2 || strings.ToLower(t.Locale) != t.Locale {
+		v.add("locale", "must be a lowercase ISO 639-1 code")
+	}
+
+	if t.Locale == q.Language {
+		v.add("locale", "question is written in %q already", q.Language)
+	}
+
+	if t.Text == "" || len(t.Text) > maxTranslationText {
+		v.add("text", "is required and must be at most %d bytes", maxTranslationText)
+	}
+
+	ids := make([]string, 0, len(t.Options))
+	for id := range t.Options {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		field, text := "options."+id, t.Options[id]
+
+		if !q.hasOption(id) {
+			v.add(field, "unknown option")
+		}
+
+		if text == "" || len(text) > maxTranslationOption {
+			v.add(field, "must have text of at most %d bytes", maxTranslationOption)
+		}
+	}
+
+	return v.err(ErrInvalidTranslation)
+}
+
+// Apply returns q with translated texts, answers stay the same.
+func (t Translation) Apply(q Question) Question {
+	q.Text = t.Text
+	q.Language = t.Locale
+
+	q.Options = append([]Option(nil), q.Options...)
+	for i, o := range q.Options {
+		if text, ok := t.Options[o.ID]; ok {
+			q.Options[i].Text = text
+		}
+	}
+
+	if q.Media != nil && t.MediaAlt != "" {
+		m := *q.Media
+		m.Alt = t.MediaAlt
+		q.Media = &m
+	}
+
+	if q.Numeric != nil && t.Unit != "" {
+		n := *q.Numeric
+		n.Unit = t.Unit
+		q.Numeric = &n
+	}
+
+	return q
+}
+
+func (q Question) hasOption(id string) bool {
+	for _, o := range q.Options {
+		if o.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Localize returns q in the first of locales it is written or translated in,
+// or as is when there is none. Outdated translations are skipped, their
+// options may no longer match the question.
+func Localize(q Question, translations []Translation, locales []string) Question {
+	byLocale := make(map[string]Translation, len(translations))
+	for _, t := range translations {
+		if t.Revision == q.Revision {
+			byLocale[t.Locale] = t
+		}
+	}
+
+	for _, locale := range locales {
+		if locale == q.Language {
+			return q
+		}
+
+		if t, ok := byLocale[locale]; ok {
+			return t.Apply(q)
+		}
+	}
+
+	return q
+}
+
+// TranslationService manages translations of published questions and shows
+// questions in the player's language.
+type TranslationService interface {
+	// Put creates or replaces the translation of the question into the
+	// locale, for the current revision of the question.
+	Put(ctx context.Context, authorID int64, t Translation) (Translation, error)
+	Delete(ctx context.Context, questionID xid.ID, locale string) error
+	List(ctx context.Context, questionID xid.ID) ([]Translation, error)
+	// Localize is Localize with translations from storage. locales are in
+	// order of preference, translations of another revision than q's are
+	// skipped.
+	Localize(ctx context.Context, q Question, locales []string) (Question, error)
+}
+
+type translationService struct {
+	questions    QuestionStorage
+	translations TranslationStorage
+}
+
+func NewTranslationService(questions QuestionStorage, translations TranslationStorage) TranslationService {
+	return &translationService{questions: questions, translations: translations}
+}
+
+func (c *translationService) Put(ctx context.Context, authorID int64, t Translation) (Translation, error) {
+	q, err := c.questions.Get(ctx, t.QuestionID)
+	if err != nil {
+		return Translation{}, err
+	}
+
+	if err := t.Validate(q); err != nil {
+		return Translation{}, err
+	}
+
+	t.ID = xid.New()
+	t.Revision = q.Revision
+	t.AuthorID = authorID
+	t.UpdatedAt = time.Now().UTC()
+
+	return c.translations.Upsert(ctx, t)
+}
+
+func (c *translationService) Delete(ctx context.Context, questionID xid.ID, locale string) error {
+	return c.translations.Delete(ctx, questionID, locale)
+}
+
+func (c *translationService) List(ctx context.Context, questionID xid.ID) ([]Translation, error) {
+	return c.translations.ListByQuestion(ctx, questionID)
+}
+
+func (c *translationService) Localize(ctx context.Context, q Question, locales []string) (Question, error) {
+	// most players play in the language of the question, skip the lookup
+	if len(locales) == 0 || locales[0] == q.Language {
+		return q, nil
+	}
+
+	translations, err := c.translations.ListByQuestion(ctx, q.ID)
+	if err != nil {
+		return Question{}, fmt.Errorf("list translations: %w", err)
+	}
+
+	return Localize(q, translations, locales), nil
+}
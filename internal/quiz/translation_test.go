@@ -0,0 +1,119 @@
+package quiz
+
+import "testing"
+
+func TestLocalize(t *testing.T) {
+	q := Question{
+		Text:     "Capital of France?",
+		Language: "en",
+		Revision: 2,
+		Options: []Option{
+			{ID: "a", Text: "Paris", Correct: true},
+			{ID: "b", Text: "Lyon"},
+		},
+	}
+
+	current := func(locale, text string) Translation {
+		return Translation{Locale: locale, Revision: 2, Text: text}
+	}
+
+	outdated := func(locale, text string) Translation {
+		return Translation{Locale: locale, Revision: 1, Text: text}
+	}
+
+	tests := []struct {
+		name         string
+		translations []Translation
+		locales      []string
+		wantText     string
+		wantLanguage string
+	}{
+		{
+			name:         "no locales",
+			translations: []Translation{current("de", "Hauptstadt von Frankreich?")},
+			wantText:     "Capital of France?",
+			wantLanguage: "en",
+		},
+		{
+			name:         "current translation",
+			translations: []Translation{current("de", "Hauptstadt von Frankreich?")},
+			locales:      []string{"de"},
+			wantText:     "Hauptstadt von Frankreich?",
+			wantLanguage: "de",
+		},
+		{
+			name:         "question language first",
+			translations: []Translation{current("de", "Hauptstadt von Frankreich?")},
+			locales:      []string{"en", "de"},
+			wantText:     "Capital of France?",
+			wantLanguage: "en",
+		},
+		{
+			name:         "outdated translation falls back to the question",
+			translations: []Translation{outdated("de", "Hauptstadt von Frankreich?")},
+			locales:      []string{"de"},
+			wantText:     "Capital of France?",
+			wantLanguage: "en",
+		},
+		{
+			name: "outdated translation falls through to the next locale",
+			translations: []Translation{
+				outdated("de", "Hauptstadt von Frankreich?"),
+				current("fr", "Capitale de la France ?"),
+			},
+			locales:      []string{"de", "fr"},
+			wantText:     "Capitale de la France ?",
+			wantLanguage: "fr",
+		},
+		{
+			name:         "newer translation than the question",
+			translations: []Translation{{Locale: "de", Revision: 3, Text: "Hauptstadt von Frankreich?"}},
+			locales:      []string{"de"},
+			wantText:     "Capital of France?",
+			wantLanguage: "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Localize(q, tt.translations, tt.locales)
+
+			if got.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", got.Text, tt.wantText)
+			}
+
+			if got.Language != tt.wantLanguage {
+				t.Errorf("Language = %q, want %q", got.Language, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestTranslationApplyKeepsAnswers(t *testing.T) {
+	q := Question{
+		Text:     "Capital of France?",
+		Language: "en",
+		Options: []Option{
+			{ID: "a", Text: "Paris", Correct: true},
+			{ID: "b", Text: "Lyon"},
+		},
+	}
+
+	got := Translation{
+		Locale:  "de",
+		Text:    "Hauptstadt von Frankreich?",
+		Options: map[string]string{"b": "Lyon (Stadt)"},
+	}.Apply(q)
+
+	if got.Options[0].Text != "Paris" || !got.Options[0].Correct {
+		t.Errorf("option a = %+v, want untranslated and correct", got.Options[0])
+	}
+
+	if got.Options[1].Text != "Lyon (Stadt)" || got.Options[1].Correct {
+		t.Errorf("option b = %+v, want translated and not correct", got.Options[1])
+	}
+
+	if q.Options[1].Text != "Lyon" {
+		t.Errorf("Apply changed the options of the question: %+v", q.Options[1])
+	}
+}
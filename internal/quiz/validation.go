@@ -0,0 +1,49 @@
+package quiz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a rule broken by a field, named by its JSON path like
+// options[2].text.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every rule a question or translation breaks. It
+// matches the sentinel of what was validated, like ErrInvalidQuestion.
+type ValidationError struct {
+	Fields []FieldError
+
+	err error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+
+	return fmt.Sprintf("%s: %s", e.err, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+type fieldErrors []FieldError
+
+func (v *fieldErrors) add(field, format string, args ...any) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns nil when no rule is broken.
+func (v fieldErrors) err(sentinel error) error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: v, err: sentinel}
+}
@@ -0,0 +1,12 @@
+package telegram
+
+import (
+	"errors"
+)
+
+var (
+	ErrInitDataMissing = errors.New("telegram init data is missing")
+	ErrInitDataInvalid = errors.New("telegram init data signature is invalid")
+	ErrInitDataExpired = errors.New("telegram init data is expired")
+	ErrBotTokenMissing = errors.New("telegram bot token is missing")
+)
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const amqpTracerName = "00-go-base-tpl-sv/amqp"
+
+type amqpCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = amqpCarrier{}
+
+func (c amqpCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+
+	return v
+}
+
+func (c amqpCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c amqpCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// StartPublish starts a producer span and writes its context into the message
+// headers, so the consumer continues the same trace.
+func StartPublish(ctx context.Context, exchange, routingKey string, msg *amqp.Publishing) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(amqpTracerName).Start(
+		ctx,
+		fmt.Sprintf("%s publish", exchange),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("rabbitmq"),
+			semconv.MessagingDestinationName(exchange),
+			semconv.MessagingRabbitmqDestinationRoutingKey(routingKey),
+			semconv.MessagingOperationPublish,
+		),
+	)
+
+	if msg.Headers == nil {
+		msg.Headers = amqp.Table{}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, amqpCarrier(msg.Headers))
+
+	return ctx, span
+}
+
+// StartConsume extracts the producer trace context from the delivery headers
+// and starts a consumer span as its child.
+func StartConsume(ctx context.Context, queue string, d amqp.Delivery) (context.Context, trace.Span) {
+	if d.Headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, amqpCarrier(d.Headers))
+	}
+
+	return otel.Tracer(amqpTracerName).Start(
+		ctx,
+		fmt.Sprintf("%s process", queue),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("rabbitmq"),
+			attribute.String("messaging.source.name", queue),
+			semconv.MessagingRabbitmqDestinationRoutingKey(d.RoutingKey),
+			attribute.String("messaging.message.id", d.MessageId),
+		),
+	)
+}